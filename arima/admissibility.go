@@ -0,0 +1,129 @@
+package arima
+
+import (
+	"fmt"
+	"math"
+)
+
+// admissibleMargin keeps damped roots this far outside the unit circle, in
+// relative terms.
+const admissibleMargin = 0.01
+
+// Correction reports which polynomials of a fitted model were damped to
+// make it admissible.
+type Correction int
+
+const (
+	CorrectionNone Correction = 0
+	// CorrectionStationarity means the AR polynomial was damped.
+	CorrectionStationarity Correction = 1 << (iota - 1)
+	// CorrectionInvertibility means the MA polynomial was damped.
+	CorrectionInvertibility
+)
+
+func (c Correction) String() string {
+	switch c {
+	case CorrectionNone:
+		return "none"
+	case CorrectionStationarity:
+		return "stationarity"
+	case CorrectionInvertibility:
+		return "invertibility"
+	case CorrectionStationarity | CorrectionInvertibility:
+		return "stationarity+invertibility"
+	}
+	return fmt.Sprintf("Correction(%d)", int(c))
+}
+
+// Correction reports the correction applied to the estimated coefficients.
+func (m *Model) Correction() Correction {
+	return m.correction
+}
+
+// isInvertibleMA reports whether the MA polynomial 1 + ma[0] z + ... has all
+// its roots outside the unit circle.
+func isInvertibleMA(ma []float64) bool {
+	negated := make([]float64, len(ma))
+	for i, v := range ma {
+		negated[i] = -v
+	}
+	return isStationaryAR(negated)
+}
+
+// isAdmissible reports whether the current coefficients of params are
+// stationary and invertible.
+func isAdmissible(params Config) bool {
+	ar, ma := params.armaCoefficients()
+	return isStationaryAR(ar) && isInvertibleMA(ma)
+}
+
+// enforceAdmissible applies policy to the AR polynomial of params if it is
+// not stationary and to its MA polynomial if it is not invertible.
+func enforceAdmissible(params Config, policy Admissibility) (Correction, error) {
+	ar, ma := params.armaCoefficients()
+	if !isFinite(ar) || !isFinite(ma) {
+		return CorrectionNone, fmt.Errorf("%w: estimated coefficients are not finite", ErrSingularSystem)
+	}
+	correction := CorrectionNone
+	if !isStationaryAR(ar) {
+		if policy == AdmissibilityReject {
+			return CorrectionNone, fmt.Errorf("%w: AR polynomial is not stationary", ErrInadmissible)
+		}
+		rho, ok := dampRoots(params.opAR, params.getOffsetsAR(), isStationaryAR)
+		if !ok {
+			return CorrectionNone, fmt.Errorf("%w: fixed AR coefficients are not stationary", ErrInadmissible)
+		}
+		scaleFactors(params.nonSeasonalAR, params.seasonalAR, params.m, rho)
+		correction |= CorrectionStationarity
+	}
+	if !isInvertibleMA(ma) {
+		if policy == AdmissibilityReject {
+			return CorrectionNone, fmt.Errorf("%w: MA polynomial is not invertible", ErrInadmissible)
+		}
+		rho, ok := dampRoots(params.opMA, params.getOffsetsMA(), isInvertibleMA)
+		if !ok {
+			return CorrectionNone, fmt.Errorf("%w: fixed MA coefficients are not invertible", ErrInadmissible)
+		}
+		scaleFactors(params.nonSeasonalMA, params.seasonalMA, params.m, rho)
+		correction |= CorrectionInvertibility
+	}
+	return correction, nil
+}
+
+// dampRoots scales the lag k coefficient of op by rho^k, which moves every
+// root of the polynomial outwards by 1/rho while keeping its lag
+// structure. rho is found by bisection as the largest value for which
+// admissible holds, less the margin. Only the coefficients at the free
+// lags are scaled. It returns the rho applied, or false if the fixed
+// coefficients alone are not admissible.
+func dampRoots(op *BackShift, free []int, admissible func([]float64) bool) (float64, bool) {
+	coeffs := append([]float64(nil), op._coeffs...)
+	isFree := make(map[int]bool, len(free))
+	for _, lag := range free {
+		isFree[lag] = true
+	}
+	scale := func(rho float64) []float64 {
+		for j, offset := range op._offsets {
+			if isFree[offset] {
+				op._coeffs[j] = coeffs[j] * math.Pow(rho, float64(offset))
+			}
+		}
+		return op.getCoefficientsFlattened()[1:]
+	}
+	if !admissible(scale(0)) {
+		copy(op._coeffs, coeffs)
+		return 0, false
+	}
+	low, high := 0.0, 1.0
+	for i := 0; i < 50; i++ {
+		mid := (low + high) / 2
+		if admissible(scale(mid)) {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	rho := low / (1 + admissibleMargin)
+	scale(rho)
+	return rho, true
+}
@@ -0,0 +1,177 @@
+package arima
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/DoOR-Team/timeseries_forecasting/boxcox"
+)
+
+const defaultRefitWindow = 30
+
+// RefitPolicy decides when Model.Append re-estimates the model from all the
+// data seen so far. Every criterion is disabled by its zero value.
+type RefitPolicy struct {
+	// Every refits once this many points were appended since the last fit.
+	Every int
+	// Window is the number of latest one-step residuals the drift criteria
+	// look at, once that many points were appended since the last fit.
+	// Defaults to 30.
+	Window int
+	// ScaleRatio refits when the root mean square of the window exceeds
+	// ScaleRatio times the residual standard deviation of the last fit,
+	// e.g. 1.5.
+	ScaleRatio float64
+	// MeanZ refits when the mean of the window is more than MeanZ standard
+	// errors away from zero, e.g. 3.
+	MeanZ float64
+}
+
+// Append adds observations, NaN for missing ones, to the end of the fitted
+// data. The forecast origin, the initial conditions of the differencing
+// and the residuals move forward with the coefficients kept as they are;
+// Sigma2 and LogLikelihood are updated. Each observation is differenced
+// from the last d+D*m levels and filtered from the state the last one
+// left, so the cost does not grow with the history. The model is then
+// refitted with the orders and options of the last fit if its
+// RefitPolicy says so, see Refits. A failed refit leaves the model updated
+// but not refitted. Models with exogenous regressors are not supported.
+func (m *Model) Append(obs ...float64) error {
+	if m.regression.hasExogenous() {
+		return fmt.Errorf("%w: cannot append to a model with regressors", ErrInvalidRegressors)
+	}
+	missing, ok := countMissing(obs)
+	if !ok {
+		return ErrNonFiniteInput
+	}
+	if len(obs) == 0 {
+		return nil
+	}
+	values := append([]float64(nil), obs...)
+	// Apply passes missing values through but rejects a slice of them only
+	if m.boxCox != nil && missing < len(values) {
+		transformed, err := boxcox.Apply(values, m.boxCox.Lambda)
+		if err != nil {
+			return err
+		}
+		values = transformed
+	}
+	if m.regression != nil {
+		for i, offset := range m.regression.effect(nil, m.trainDataSize, len(values)) {
+			values[i] -= offset
+		}
+	}
+
+	for _, y := range values {
+		if err := m.appendPoint(y); err != nil {
+			return err
+		}
+	}
+	m.observed = append(m.observed, obs...)
+	m.missing += missing
+	m.sinceFit += len(obs)
+
+	if !m.options.Refit.due(m) {
+		return nil
+	}
+	refitted, err := Fit(m.observed, m.spec, m.options)
+	if err != nil {
+		return fmt.Errorf("refit after %d appended points: %w", m.sinceFit, err)
+	}
+	numRefits := m.numRefits + 1
+	*m = *refitted
+	m.numRefits = numRefits
+	return nil
+}
+
+// Refits returns the number of times Append has refitted the model.
+func (m *Model) Refits() int {
+	return m.numRefits
+}
+
+// appendPoint extends the fitted data by y, on the scale the model was
+// fitted on, and moves the residuals and the likelihood one step forward.
+func (m *Model) appendPoint(y float64) error {
+	// the data past the training part are never used, forecasts start at
+	// the end of the training data
+	initial := len(m.Params.differencingPolynomial()) - 1
+	levels := append(append([]float64(nil), m.data[m.trainDataSize-initial:m.trainDataSize]...), y)
+	differenced, err := differenceWith(m.Params, levels)
+	if err != nil {
+		return err
+	}
+	x := differenced[0]
+	residual, tail := m.tail.next(m.Params, x)
+	filter := m.filter
+	v, f := math.NaN(), 1.0
+	if filter != nil {
+		filter = filter.clone()
+		var ok bool
+		if v, f, ok = filter.step(x); !ok {
+			return fmt.Errorf("%w: innovation variance is not positive", ErrSingularSystem)
+		}
+		if !math.IsNaN(v) {
+			m.sumSquares += v * v / f
+			m.sumLogF += math.Log(f)
+			m.nobs++
+		}
+	} else if !math.IsNaN(residual) {
+		m.sumSquares += residual * residual
+		m.nobs++
+	}
+	m.concentrate()
+
+	m.data = append(m.data[:m.trainDataSize], y)
+	m.trainDataSize++
+	m.stationary = append(m.stationary, x)
+	m.tail = tail
+	m.filter = filter
+	if m.method == MethodML || m.method == MethodCSSML {
+		m.residuals = append(m.residuals, v/math.Sqrt(f))
+		m.innovations = append(m.innovations, v)
+	} else {
+		m.residuals = append(m.residuals, residual)
+		m.innovations = m.residuals
+	}
+	return nil
+}
+
+// due reports whether the model needs a refit under p.
+func (p RefitPolicy) due(m *Model) bool {
+	if p.Every > 0 && m.sinceFit >= p.Every {
+		return true
+	}
+	if p.ScaleRatio <= 0 && p.MeanZ <= 0 {
+		return false
+	}
+	window := p.Window
+	if window <= 0 {
+		window = defaultRefitWindow
+	}
+	if m.sinceFit < window || len(m.residuals) < window {
+		return false
+	}
+	var recent []float64
+	for _, e := range m.residuals[len(m.residuals)-window:] {
+		if !math.IsNaN(e) {
+			recent = append(recent, e)
+		}
+	}
+	if len(recent) == 0 {
+		return false
+	}
+	sd := m.fitSD
+	if math.IsNaN(sd) || sd == 0 {
+		sd = m.RMSE
+	}
+	sum, squareSum := 0.0, 0.0
+	for _, e := range recent {
+		sum += e
+		squareSum += e * e
+	}
+	n := float64(len(recent))
+	if p.ScaleRatio > 0 && math.Sqrt(squareSum/n) > p.ScaleRatio*sd {
+		return true
+	}
+	return p.MeanZ > 0 && math.Abs(sum/n) > p.MeanZ*sd/math.Sqrt(n)
+}
@@ -1,34 +1,220 @@
 package arima
 
 import (
+	"fmt"
+	"math"
 	"strconv"
 
 	"github.com/DoOR-Team/goutils/log"
+	"github.com/DoOR-Team/timeseries_forecasting/boxcox"
 )
 
-func ForeCastARIMA(data []float64, forecastSize int, params Config) *Result {
-	p := params.p
-	d := params.d
-	q := params.q
-	P := params.P
-	D := params.D
-	Q := params.Q
-	m := params.m
-	paramsForecast := NewConfig(p, d, q, P, D, Q, m)
-	paramsXValidation := NewConfig(p, d, q, P, D, Q, m)
+// Method selects the estimator used by Fit.
+type Method int
+
+const (
+	// MethodHannanRissanen iterates least squares regressions on lagged
+	// values and residuals, keeping the iteration with the best hold-out
+	// RMSE. It is the default.
+	MethodHannanRissanen Method = iota
+	// MethodML maximizes the exact Gaussian likelihood, evaluated with a
+	// Kalman filter on the state-space form of the ARMA model.
+	MethodML
+	// MethodCSS minimizes the conditional sum of squared residuals.
+	MethodCSS
+	// MethodCSSML uses the CSS estimates to start the exact maximum
+	// likelihood optimization.
+	MethodCSSML
+)
+
+// Inclusion is a tri-state option whose zero value selects a default that
+// depends on the model.
+type Inclusion int
+
+const (
+	InclusionDefault Inclusion = iota
+	Include
+	Exclude
+)
+
+// Admissibility selects what Fit does when the estimated AR polynomial is
+// not stationary or the MA polynomial is not invertible. The ML and CSS
+// optimizers never leave the admissible region, so this mostly concerns
+// Hannan-Rissanen estimates.
+type Admissibility int
+
+const (
+	// AdmissibilityDamp shrinks the offending polynomial until its roots lie
+	// outside the unit circle. It is the default.
+	AdmissibilityDamp Admissibility = iota
+	// AdmissibilityReject fails the fit with ErrInadmissible.
+	AdmissibilityReject
+)
+
+// FitOptions controls how Fit estimates a model. The zero value selects
+// the defaults.
+type FitOptions struct {
+	// ValidationPercentage, if positive, is the share of the series held
+	// out to compute the RMSE reported by Model.RMSE and Result.GetRMSE,
+	// e.g. 0.15, at the cost of estimating the model a second time on the
+	// rest. By default the model is estimated once and the RMSE is the
+	// square root of Model.Sigma2. Prediction intervals are
+	// scaled by the residual variance either way.
+	ValidationPercentage float64
+	// Method selects the estimator. Defaults to MethodHannanRissanen.
+	Method Method
+	// Xreg holds exogenous regressors, one slice per regressor with one
+	// value per observation. When set, the model is a regression with
+	// ARIMA errors and must be forecast with ForecastWithRegressors.
+	Xreg [][]float64
+	// IncludeMean adds a constant mean. It only applies to models without
+	// differencing (d+D = 0), where it defaults to Include.
+	IncludeMean Inclusion
+	// IncludeDrift adds a linear trend in time, which is the constant of the
+	// differenced series when d+D = 1. It defaults to Exclude and is not
+	// allowed when d+D > 1.
+	IncludeDrift Inclusion
+	// Admissibility handles non-stationary or non-invertible estimates. The
+	// correction applied is reported by Model.Correction.
+	Admissibility Admissibility
+	// BoxCox transforms the data, which must be positive, before fitting.
+	// Forecasts, prediction intervals and fitted values are transformed
+	// back; an automatic lambda is chosen once from the data.
+	BoxCox *boxcox.Transform
+	// Refit decides when Model.Append re-estimates the model. The zero
+	// value never does.
+	Refit RefitPolicy
+}
+
+// constantTerms resolves which of the mean and drift terms spec includes.
+func (opts FitOptions) constantTerms(spec Config) (intercept, drift bool, err error) {
+	differences := spec.d
+	if spec.m > 0 {
+		differences += spec.D
+	}
+	intercept = differences == 0 && opts.IncludeMean != Exclude
+	drift = opts.IncludeDrift == Include
+	if drift && differences > 1 {
+		return false, false, fmt.Errorf("%w: drift is not allowed when d+D > 1, have d+D=%d",
+			ErrInvalidOrder, differences)
+	}
+	return intercept, drift, nil
+}
+
+// Fit estimates the ARIMA model described by spec on data. Only the orders
+// of spec are used; its coefficients are re-estimated. The returned Model
+// can be forecast any number of times without refitting.
+//
+// NaN values in data mark missing observations. The exact likelihood of
+// MethodML and MethodCSSML skips them in the Kalman filter; the other
+// methods leave them out of their sums and regressions. Forecasts
+// condition on the observed values. The count is reported by
+// Model.NumMissing.
+func Fit(data []float64, spec Config, opts FitOptions) (*Model, error) {
+	missing, ok := countMissing(data)
+	if !ok {
+		return nil, ErrNonFiniteInput
+	}
+	if missing == len(data) {
+		return nil, fmt.Errorf("%w: every observation is missing", ErrInsufficientData)
+	}
+	validationPercentage := opts.ValidationPercentage
+	if validationPercentage < 0 || validationPercentage >= 1 {
+		return nil, fmt.Errorf("validation percentage must be in [0, 1), got %v", validationPercentage)
+	}
+	paramsForecast, err := spec.clone()
+	if err != nil {
+		return nil, err
+	}
+	paramsXValidation, err := spec.clone()
+	if err != nil {
+		return nil, err
+	}
+	trainData := make([]float64, len(data))
+	copy(trainData, data)
+
+	var transform *boxcox.Transform
+	if opts.BoxCox != nil {
+		resolved, err := opts.BoxCox.Resolve(data, spec.m)
+		if err != nil {
+			return nil, err
+		}
+		if trainData, err = boxcox.Apply(data, resolved.Lambda); err != nil {
+			return nil, err
+		}
+		transform = &resolved
+	}
+
+	// regress out exogenous regressors and constant terms, the ARIMA model
+	// is fitted to the errors
+	intercept, drift, err := opts.constantTerms(spec)
+	if err != nil {
+		return nil, err
+	}
+	var reg *regression
+	if opts.Xreg != nil || intercept || drift {
+		if reg, err = newRegression(trainData, opts.Xreg, spec, intercept, drift); err != nil {
+			return nil, err
+		}
+		if err = reg.estimate(spec, opts.Method); err != nil {
+			return nil, err
+		}
+		trainData = reg.errorsOf(trainData)
+	}
+
 	// estimate ARIMA model parameters for forecasting
-	fittedModel := estimateARIMA(
-		paramsForecast, data, len(data), len(data)+1)
+	fittedModel, err := estimateARIMA(
+		paramsForecast, trainData, len(trainData), len(trainData)+1, opts.Method, opts.Admissibility)
+	if err != nil {
+		return nil, err
+	}
 
-	// compute RMSE to be used in confidence interval computation
-	rmseValidation := computeRMSEValidation(
-		data, testSetPercentage, paramsXValidation)
-	fittedModel.RMSE = rmseValidation
+	// the hold-out RMSE needs a second fit and is only computed on request;
+	// it scales the intervals only when the residual variance is unavailable
+	fittedModel.RMSE = math.Sqrt(fittedModel.sigma2)
+	if validationPercentage > 0 {
+		if fittedModel.RMSE, err = computeRMSEValidation(
+			trainData, validationPercentage, paramsXValidation, opts.Method, opts.Admissibility); err != nil {
+			return nil, err
+		}
+	}
+	fittedModel.regression = reg
+	fittedModel.boxCox = transform
+	fittedModel.missing = missing
+	fittedModel.observed = append([]float64(nil), data...)
+	if fittedModel.spec, err = spec.clone(); err != nil {
+		return nil, err
+	}
+	fittedModel.options = opts
+	fittedModel.fitSD = math.Sqrt(fittedModel.sigma2)
+	return fittedModel, nil
+}
 
-	forecastResult := fittedModel.forecast(forecastSize)
+// ForeCastARIMA fits the given model to data and forecasts forecastSize
+// points ahead, with prediction intervals at each of levels (95% if none
+// is given). NaN values mark missing observations, see Fit. Bad orders,
+// short series and infinite input are reported through the sentinel errors
+// of this package instead of aborting.
+func ForeCastARIMA(data []float64, forecastSize int, params Config, levels ...float64) (*Result, error) {
+	if forecastSize <= 0 {
+		return nil, fmt.Errorf("%w: forecast size must be positive, got %d", ErrInvalidOrder, forecastSize)
+	}
+	if len(levels) == 0 {
+		levels = []float64{defaultConfidenceLevel}
+	}
+	if err := validateLevels(levels); err != nil {
+		return nil, err
+	}
+	fittedModel, err := Fit(data, params, FitOptions{})
+	if err != nil {
+		return nil, err
+	}
 
-	// populate confidence interval
-	forecastResult.SetSigma2AndPredicationInterval(fittedModel.GetParams())
+	// forecast and populate confidence interval
+	forecastResult, err := fittedModel.ForecastInterval(forecastSize, levels)
+	if err != nil {
+		return nil, err
+	}
 
 	// add logging messages
 	log.Debug("{" +
@@ -38,5 +224,5 @@ func ForeCastARIMA(data []float64, forecastSize int, params Config) *Result {
 		"}")
 
 	// successfully built ARIMA model and its forecast
-	return forecastResult
+	return forecastResult, nil
 }
@@ -2,12 +2,19 @@ package arima
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"math"
+	"math/cmplx"
+	"math/rand"
 	"strconv"
+	"sync"
 	"testing"
 
 	"github.com/DoOR-Team/goutils/log"
+	"github.com/DoOR-Team/timeseries_forecasting/arima/matrix"
+	"github.com/DoOR-Team/timeseries_forecasting/boxcox"
+	"github.com/DoOR-Team/timeseries_forecasting/distribution"
 )
 
 func TestArima(t *testing.T) {
@@ -23,7 +30,14 @@ func TestArima(t *testing.T) {
 	Q := 0
 	m := 0
 	forecastSize := 10
-	forecastResult := ForeCastARIMA(dataArray, forecastSize, NewConfig(p, d, q, P, D, Q, m))
+	config, err := NewConfig(p, d, q, P, D, Q, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forecastResult, err := ForeCastARIMA(dataArray, forecastSize, config)
+	if err != nil {
+		t.Fatal(err)
+	}
 	forecast := forecastResult.GetForecast()
 	upper := forecastResult.GetForecastUpperConf()
 	lower := forecastResult.GetForecastLowerConf()
@@ -35,11 +49,1284 @@ func TestArima(t *testing.T) {
 
 }
 
+func TestArimaErrors(t *testing.T) {
+	if _, err := NewConfig(-1, 0, 0, 0, 0, 0, 0); !errors.Is(err, ErrInvalidOrder) {
+		t.Fatalf("expected ErrInvalidOrder, got %v", err)
+	}
+
+	config, err := NewConfig(2, 1, 1, 0, 0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ForeCastARIMA([]float64{1, 2, 3}, 2, config); !errors.Is(err, ErrInsufficientData) {
+		t.Fatalf("expected ErrInsufficientData, got %v", err)
+	}
+	if _, err := ForeCastARIMA([]float64{1, 2, math.Inf(1), 4}, 2, config); !errors.Is(err, ErrNonFiniteInput) {
+		t.Fatalf("expected ErrNonFiniteInput, got %v", err)
+	}
+	if _, err := NewBackShift(-1, true); !errors.Is(err, ErrInvalidOrder) {
+		t.Fatalf("expected ErrInvalidOrder, got %v", err)
+	}
+	if _, err := FitYuleWalker([]float64{1, 2}, 3); !errors.Is(err, ErrInsufficientData) {
+		t.Fatalf("expected ErrInsufficientData, got %v", err)
+	}
+}
+
+func TestFitForecastLifecycle(t *testing.T) {
+	config, err := NewConfig(2, 0, 1, 0, 0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	model, err := Fit(cscchris_val, config, FitOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	short, err := model.Forecast(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	long, err := model.ForecastInterval(6, []float64{0.8, 0.95})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range short.GetForecast() {
+		if math.Abs(short.GetForecast()[i]-long.GetForecast()[i]) > 1e-9 {
+			t.Fatalf("forecast %d differs between horizons: %v vs %v", i, short.GetForecast()[i], long.GetForecast()[i])
+		}
+	}
+	upper80 := long.GetForecastUpperConfAt(0.8)
+	upper95 := long.GetForecastUpperConfAt(0.95)
+	if upper80 == nil || upper95 == nil {
+		t.Fatal("missing requested confidence level")
+	}
+	for i := range upper80 {
+		if upper80[i] > upper95[i] {
+			t.Fatalf("80%% bound above 95%% bound at %d", i)
+		}
+	}
+	if _, err := model.ForecastInterval(3, []float64{95}); err == nil {
+		t.Fatal("expected error for confidence level outside (0, 1)")
+	}
+
+	// the hold-out RMSE, and the second fit it takes, are opt-in
+	if math.Abs(model.RMSE-math.Sqrt(model.Sigma2())) > 1e-12 || short.GetRMSE() != model.RMSE {
+		t.Fatalf("expected the in-sample RMSE %v, got %v", math.Sqrt(model.Sigma2()), model.RMSE)
+	}
+	once, err := Fit(cscchris_val, config, FitOptions{IncludeMean: Exclude})
+	if err != nil {
+		t.Fatal(err)
+	}
+	validated, err := Fit(cscchris_val, config, FitOptions{IncludeMean: Exclude, ValidationPercentage: 0.15})
+	if err != nil {
+		t.Fatal(err)
+	}
+	scratch, err := config.clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+	holdOut, err := computeRMSEValidation(cscchris_val, 0.15, scratch, MethodHannanRissanen, AdmissibilityDamp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if validated.RMSE != holdOut || validated.Sigma2() != once.Sigma2() {
+		t.Fatalf("expected the hold-out RMSE %v and unchanged estimates, got %v", holdOut, validated.RMSE)
+	}
+	if _, err := Fit(cscchris_val, config, FitOptions{ValidationPercentage: -0.1}); err == nil {
+		t.Fatal("expected error for a negative validation percentage")
+	}
+}
+
+func TestConfidenceLevels(t *testing.T) {
+	for _, c := range []struct{ level, z float64 }{
+		{0.5, 0.6744897501960817},
+		{0.8, 1.2815515655446004},
+		{0.95, 1.959963984540054},
+		{0.99, 2.5758293035489004},
+	} {
+		if z := levelToConstant(c.level); math.Abs(z-c.z) > 1e-12 {
+			t.Fatalf("level %v: expected %v, got %v", c.level, c.z, z)
+		}
+	}
+
+	config, err := NewConfig(1, 1, 1, 0, 0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	levels := []float64{0.5, 0.8, 0.95, 0.99}
+	result, err := ForeCastARIMA(cscchris_val, 4, config, levels...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.GetConfLevels()) != len(levels) {
+		t.Fatalf("expected %d levels, got %v", len(levels), result.GetConfLevels())
+	}
+	for i := 1; i < len(levels); i++ {
+		narrow := result.GetForecastUpperConfAt(levels[i-1])
+		wide := result.GetForecastUpperConfAt(levels[i])
+		for h := range narrow {
+			if narrow[h] > wide[h] {
+				t.Fatalf("level %v wider than %v at horizon %d", levels[i-1], levels[i], h)
+			}
+		}
+	}
+	if _, err := ForeCastARIMA(cscchris_val, 4, config, 0.95, 0.95); err == nil {
+		t.Fatal("expected error for duplicate confidence level")
+	}
+}
+
+func TestAuto(t *testing.T) {
+	// random walk with AR(1) increments: should be differenced once
+	rng := rand.New(rand.NewSource(1))
+	walk := make([]float64, 200)
+	increment := 0.0
+	for i := 1; i < len(walk); i++ {
+		increment = 0.5*increment + rng.NormFloat64()
+		walk[i] = walk[i-1] + increment
+	}
+	model, candidates, err := Auto(walk, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if model.GetParams().d != 1 {
+		t.Fatalf("expected d=1, got %s", model.GetParams())
+	}
+	if len(candidates) < 4 {
+		t.Fatalf("expected at least the 4 starting candidates, got %d", len(candidates))
+	}
+	aicc := model.AICc()
+	for _, c := range candidates {
+		if c.Err == nil && c.AICc < aicc {
+			t.Fatalf("candidate %s has lower AICc %v than chosen %v", c.Spec, c.AICc, aicc)
+		}
+	}
+	if _, err := model.Forecast(5); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := DefaultAutoOptions()
+	opts.Exhaustive = true
+	opts.MaxP, opts.MaxQ = 2, 2
+	_, candidates, err = Auto(cscchris_val, 0, &opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 9 orders, with and without a mean
+	if len(candidates) != 18 {
+		t.Fatalf("expected 18 candidates from exhaustive search, got %d", len(candidates))
+	}
+
+	// the zero value selects the defaults, differencing included
+	zeroModel, _, err := Auto(walk, 0, &AutoOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if zeroModel.GetParams().String() != model.GetParams().String() {
+		t.Fatalf("zero options chose %s, defaults %s", zeroModel.GetParams(), model.GetParams())
+	}
+	// a fixed d and a negative bound, which excludes the term
+	zero := 0
+	fixed, candidates, err := Auto(walk, 0, &AutoOptions{D: &zero, MaxQ: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fixed.GetParams().d != 0 {
+		t.Fatalf("expected the fixed d=0, got %s", fixed.GetParams())
+	}
+	for _, c := range candidates {
+		if c.Spec.q != 0 {
+			t.Fatalf("candidate %s has MA terms with MaxQ < 0", c.Spec)
+		}
+	}
+	negative := -1
+	if _, _, err := Auto(walk, 0, &AutoOptions{D: &negative}); !errors.Is(err, ErrInvalidOrder) {
+		t.Fatalf("expected ErrInvalidOrder for d=-1, got %v", err)
+	}
+}
+
+func TestAutoNonSeasonal(t *testing.T) {
+	// AICc still picks spurious terms now and then, but a non-seasonal
+	// series should rarely get seasonal ones
+	config, err := NewConfig(1, 0, 0, 0, 0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seasonal := 0
+	for seed := int64(0); seed < 10; seed++ {
+		data, err := Simulate(config, Process{AR: []float64{0.5}}, 120, 100, rand.NewSource(seed))
+		if err != nil {
+			t.Fatal(err)
+		}
+		model, _, err := Auto(data, 12, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if spec := model.GetParams(); spec.P > 0 || spec.D > 0 || spec.Q > 0 {
+			seasonal++
+		}
+	}
+	if seasonal > 2 {
+		t.Fatalf("%d of 10 non-seasonal AR(1) series got seasonal terms", seasonal)
+	}
+}
+
+func TestInformationCriteria(t *testing.T) {
+	config, err := NewConfig(1, 1, 1, 0, 0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	model, err := Fit(cscchris_val, config, FitOptions{IncludeDrift: Include})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// every one of the 29 differenced points, none lost to conditioning
+	if model.NumObs() != 29 {
+		t.Fatalf("expected 29 observations, got %d", model.NumObs())
+	}
+	if model.NumParams() != 4 {
+		t.Fatalf("expected 4 parameters, got %d", model.NumParams())
+	}
+	n, k := float64(model.NumObs()), float64(model.NumParams())
+	exact, ok := model.Params.exactFilter(model.stationary, nil)
+	if !ok {
+		t.Fatal("fitted coefficients are not admissible")
+	}
+	// sigma^2 is the one the likelihood concentrates out
+	expectedLogLik, expectedSigma2 := exact.logLikelihood()
+	if math.Abs(model.LogLikelihood()-expectedLogLik) > 1e-9 || math.Abs(model.Sigma2()-expectedSigma2) > 1e-12 {
+		t.Fatalf("log-likelihood %v and sigma2 %v, expected the exact %v and %v",
+			model.LogLikelihood(), model.Sigma2(), expectedLogLik, expectedSigma2)
+	}
+	if math.Abs(model.AICc()-model.AIC()-2*k*(k+1)/(n-k-1)) > 1e-9 {
+		t.Fatalf("inconsistent AICc %v for AIC %v", model.AICc(), model.AIC())
+	}
+	if math.Abs(model.BIC()-model.AIC()-k*(math.Log(n)-2)) > 1e-9 {
+		t.Fatalf("inconsistent BIC %v for AIC %v", model.BIC(), model.AIC())
+	}
+
+	// conditional estimators of different orders are compared on the same
+	// sample
+	data := generateARMA(200, 0.5, 0, 67)
+	for _, method := range []Method{MethodHannanRissanen, MethodCSS} {
+		nobs := -1
+		for _, p := range []int{1, 3} {
+			spec, err := NewConfig(p, 0, 1, 0, 0, 0, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			model, err := Fit(data, spec, FitOptions{Method: method})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if nobs >= 0 && model.NumObs() != nobs {
+				t.Fatalf("method %d: ARMA(%d,1) has %d observations, ARMA(1,1) %d", method, p, model.NumObs(), nobs)
+			}
+			nobs = model.NumObs()
+		}
+		if nobs != len(data) {
+			t.Fatalf("method %d: expected %d observations, got %d", method, len(data), nobs)
+		}
+	}
+
+	// the cross lags of a seasonal model are not parameters: p+q+P+Q, the
+	// mean and sigma^2
+	seasonal, err := NewConfig(1, 0, 1, 1, 0, 1, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, method := range []Method{MethodHannanRissanen, MethodCSS, MethodML} {
+		model, err := Fit(data, seasonal, FitOptions{Method: method})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if model.NumParams() != 6 {
+			t.Fatalf("method %d: expected 6 parameters for (1,0,1)(1,0,1)_4, got %d", method, model.NumParams())
+		}
+	}
+}
+
+// generateARMA returns n points of a zero-mean ARMA(1,1) process after a
+// burn-in of 100 points.
+func generateARMA(n int, phi, theta float64, seed int64) []float64 {
+	rng := rand.New(rand.NewSource(seed))
+	data := make([]float64, n+100)
+	previousError := 0.0
+	for i := 1; i < len(data); i++ {
+		e := rng.NormFloat64()
+		data[i] = phi*data[i-1] + e + theta*previousError
+		previousError = e
+	}
+	return data[100:]
+}
+
+func TestFitMaximumLikelihood(t *testing.T) {
+	data := generateARMA(1000, 0.6, 0.3, 7)
+	config, err := NewConfig(1, 0, 1, 0, 0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	model, err := Fit(data, config, FitOptions{Method: MethodML})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ar, ma := model.GetParams().armaCoefficients()
+	if math.Abs(ar[0]-0.6) > 0.1 || math.Abs(ma[0]-0.3) > 0.1 {
+		t.Fatalf("expected phi=0.6, theta=0.3, got phi=%v, theta=%v", ar[0], ma[0])
+	}
+	if math.Abs(model.Sigma2()-1) > 0.15 {
+		t.Fatalf("expected sigma2 close to 1, got %v", model.Sigma2())
+	}
+	if model.NumObs() != len(data) {
+		t.Fatalf("exact likelihood should use all %d observations, got %d", len(data), model.NumObs())
+	}
+	if _, err := model.Forecast(5); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFitConditionalSumOfSquares(t *testing.T) {
+	data := generateARMA(1000, 0.6, 0.3, 7)
+	for _, method := range []Method{MethodCSS, MethodCSSML} {
+		config, err := NewConfig(1, 0, 1, 0, 0, 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		model, err := Fit(data, config, FitOptions{Method: method})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ar, ma := model.GetParams().armaCoefficients()
+		if math.Abs(ar[0]-0.6) > 0.1 || math.Abs(ma[0]-0.3) > 0.1 {
+			t.Fatalf("method %d: expected phi=0.6, theta=0.3, got phi=%v, theta=%v", method, ar[0], ma[0])
+		}
+	}
+}
+
+func TestCoefficientStandardErrors(t *testing.T) {
+	data := generateARMA(1000, 0.6, 0, 11)
+	config, err := NewConfig(1, 0, 0, 0, 0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	model, err := Fit(data, config, FitOptions{Method: MethodML})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ar1, ok, err := model.Coefficient("ar1")
+	if err != nil || !ok {
+		t.Fatalf("ar1 not found: %v", err)
+	}
+	// asymptotic standard error of an AR(1) coefficient: sqrt((1 - phi^2) / n)
+	expected := math.Sqrt((1 - 0.6*0.6) / 1000)
+	if math.Abs(ar1.StdErr-expected) > 0.005 {
+		t.Fatalf("expected ar1 standard error near %v, got %v", expected, ar1.StdErr)
+	}
+	if ar1.PValue > 1e-6 || math.Abs(ar1.TStat-ar1.Value/ar1.StdErr) > 1e-12 {
+		t.Fatalf("unexpected t-statistic %v and p-value %v", ar1.TStat, ar1.PValue)
+	}
+	covariance, err := model.CovarianceMatrix()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(covariance) != 2 {
+		t.Fatalf("expected 2x2 covariance for ar1 and mean, got %d rows", len(covariance))
+	}
+
+	seasonal, err := NewConfig(1, 0, 1, 1, 0, 0, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	model, err = Fit(data, seasonal, FitOptions{Method: MethodCSS})
+	if err != nil {
+		t.Fatal(err)
+	}
+	coefficients, err := model.Coefficients()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, c := range coefficients {
+		names = append(names, c.Name)
+	}
+	if fmt.Sprint(names) != "[ar1 sar1 ma1 mean]" {
+		t.Fatalf("unexpected coefficient names %v", names)
+	}
+}
+
+func TestMultiplicativeSeasonal(t *testing.T) {
+	config, err := NewConfig(1, 0, 1, 1, 0, 1, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	process := Process{AR: []float64{0.5, 0.6}, MA: []float64{0.3, -0.4}}
+	data, err := Simulate(config, process, 2000, 200, rand.NewSource(61))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, method := range []Method{MethodML, MethodCSS} {
+		model, err := Fit(data, config, FitOptions{Method: method, IncludeMean: Exclude})
+		if err != nil {
+			t.Fatal(err)
+		}
+		coefficients, err := model.Coefficients()
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := map[string]float64{"ar1": 0.5, "sar1": 0.6, "ma1": 0.3, "sma1": -0.4}
+		if len(coefficients) != len(expected) {
+			t.Fatalf("method %v: expected 4 coefficients, got %v", method, coefficients)
+		}
+		for _, c := range coefficients {
+			if math.Abs(c.Value-expected[c.Name]) > 0.08 {
+				t.Fatalf("method %v: %s = %v, expected %v", method, c.Name, c.Value, expected[c.Name])
+			}
+		}
+		// the cross lags are the products of the factors
+		ar, ma := model.GetParams().armaCoefficients()
+		if math.Abs(ar[4]+ar[0]*ar[3]) > 1e-12 || math.Abs(ma[4]-ma[0]*ma[3]) > 1e-12 {
+			t.Fatalf("method %v: cross lags are not multiplicative: ar=%v, ma=%v", method, ar, ma)
+		}
+		// p+q+P+Q plus sigma^2
+		if model.NumParams() != 5 {
+			t.Fatalf("method %v: expected 5 parameters, got %d", method, model.NumParams())
+		}
+	}
+}
+
+func TestRegressionWithARIMAErrors(t *testing.T) {
+	rng := rand.New(rand.NewSource(5))
+	n := 300
+	noise := generateARMA(n+10, 0.5, 0, 5)
+	temperature := make([]float64, n+10)
+	data := make([]float64, n+10)
+	level := 0.0
+	for i := range data {
+		temperature[i] = 10*math.Sin(float64(i)/7) + rng.NormFloat64()
+		level += noise[i]
+		data[i] = 100 + 2*temperature[i] + level
+	}
+	config, err := NewConfig(1, 1, 0, 0, 0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, method := range []Method{MethodHannanRissanen, MethodML} {
+		model, err := Fit(data[:n], config, FitOptions{Method: method, Xreg: [][]float64{temperature[:n]}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		beta, ok, err := model.Coefficient("xreg1")
+		if err != nil || !ok {
+			t.Fatalf("xreg1 not found: %v", err)
+		}
+		if math.Abs(beta.Value-2) > 0.1 || !(beta.StdErr > 0) {
+			t.Fatalf("method %d: expected beta near 2, got %v (se %v)", method, beta.Value, beta.StdErr)
+		}
+		if _, err := model.Forecast(10); !errors.Is(err, ErrInvalidRegressors) {
+			t.Fatalf("expected ErrInvalidRegressors without future regressors, got %v", err)
+		}
+		result, err := model.ForecastWithRegressors([][]float64{temperature[n:]})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result.GetForecast()) != 10 {
+			t.Fatalf("expected 10 forecasts, got %d", len(result.GetForecast()))
+		}
+		for i, f := range result.GetForecast() {
+			if f < result.GetForecastLowerConf()[i] || f > result.GetForecastUpperConf()[i] {
+				t.Fatalf("forecast %d outside its interval", i)
+			}
+		}
+	}
+
+	// Hannan-Rissanen estimates beta jointly with the errors by feasible
+	// GLS: with persistent errors and regressor it agrees with ML, where
+	// least squares alone does not
+	rng = rand.New(rand.NewSource(4))
+	x := make([]float64, n)
+	y := make([]float64, n)
+	u := 0.0
+	for i := range y {
+		if i > 0 {
+			x[i] = 0.95*x[i-1] + rng.NormFloat64()
+		}
+		u = 0.9*u + rng.NormFloat64()
+		y[i] = 2*x[i] + u
+	}
+	ar1, err := NewConfig(1, 0, 0, 0, 0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hr, err := Fit(y, ar1, FitOptions{Xreg: [][]float64{x}, IncludeMean: Exclude})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ml, err := Fit(y, ar1, FitOptions{Xreg: [][]float64{x}, IncludeMean: Exclude, Method: MethodML})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ols, err := leastSquares(hr.regression.regressors, hr.regression.response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(hr.regression.beta[0]-ml.regression.beta[0]) > 0.005 || math.Abs(ols[0]-ml.regression.beta[0]) < 0.1 {
+		t.Fatalf("beta: Hannan-Rissanen %v, ML %v, least squares %v",
+			hr.regression.beta[0], ml.regression.beta[0], ols[0])
+	}
+}
+
+func TestMeanAndDrift(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	data := make([]float64, 300)
+	for i := 1; i < len(data); i++ {
+		data[i] = data[i-1] + 0.5 + rng.NormFloat64()
+	}
+	config, err := NewConfig(0, 1, 0, 0, 0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// d=1 defaults to no constant: a random walk forecast is flat
+	model, err := Fit(data, config, FitOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	flat, err := model.Forecast(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if flat.GetForecast()[2] != data[len(data)-1] {
+		t.Fatalf("expected flat forecast %v, got %v", data[len(data)-1], flat.GetForecast())
+	}
+
+	model, err = Fit(data, config, FitOptions{IncludeDrift: Include, Method: MethodML})
+	if err != nil {
+		t.Fatal(err)
+	}
+	drift, ok, err := model.Coefficient("drift")
+	if err != nil || !ok {
+		t.Fatalf("drift not found: %v", err)
+	}
+	if math.Abs(drift.Value-0.5) > 3*drift.StdErr || drift.StdErr > 0.1 {
+		t.Fatalf("expected drift near 0.5, got %v (se %v)", drift.Value, drift.StdErr)
+	}
+	trending, err := model.Forecast(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	step := trending.GetForecast()[2] - trending.GetForecast()[1]
+	if math.Abs(step-drift.Value) > 1e-9 {
+		t.Fatalf("expected forecasts to grow by the drift %v, got %v", drift.Value, step)
+	}
+
+	twice, err := NewConfig(0, 2, 0, 0, 0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Fit(data, twice, FitOptions{IncludeDrift: Include}); !errors.Is(err, ErrInvalidOrder) {
+		t.Fatalf("expected ErrInvalidOrder for drift with d=2, got %v", err)
+	}
+}
+
+func TestAdmissibility(t *testing.T) {
+	config, err := NewConfig(2, 0, 1, 0, 0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.setParamsFromVector(matrix.NewInsightVectorWithData([]float64{0.5, 0.7, -1.5}, false))
+	if _, err := enforceAdmissible(config, AdmissibilityReject); !errors.Is(err, ErrInadmissible) {
+		t.Fatalf("expected ErrInadmissible, got %v", err)
+	}
+	correction, err := enforceAdmissible(config, AdmissibilityDamp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if correction != CorrectionStationarity|CorrectionInvertibility {
+		t.Fatalf("expected both polynomials damped, got %v", correction)
+	}
+	ar, ma := config.armaCoefficients()
+	if !isStationaryAR(ar) || !isInvertibleMA(ma) {
+		t.Fatalf("damped coefficients are not admissible: ar=%v, ma=%v", ar, ma)
+	}
+	// damping keeps the roots just outside the unit circle
+	if math.Abs(ma[0]+1/(1+admissibleMargin)) > 1e-6 {
+		t.Fatalf("expected ma1 near -1/(1+margin), got %v", ma[0])
+	}
+
+	config, err = NewConfig(1, 0, 1, 0, 0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	model, err := Fit(generateARMA(500, 0.6, 0.3, 5), config, FitOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if model.Correction() != CorrectionNone {
+		t.Fatalf("expected no correction for a stationary series, got %v", model.Correction())
+	}
+}
+
+func TestPolynomialRoots(t *testing.T) {
+	// (1 - 0.5B)(1 + 0.25B^2) has roots 2 and +-2i
+	poly := Polynomial{1, -0.5}.Multiply(Polynomial{1, 0, 0.25})
+	if poly.Degree() != 3 {
+		t.Fatalf("expected degree 3, got %d", poly.Degree())
+	}
+	roots, err := poly.Roots()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(roots) != 3 {
+		t.Fatalf("expected 3 roots, got %v", roots)
+	}
+	for _, want := range []complex128{2, 2i, -2i} {
+		found := false
+		for _, root := range roots {
+			if cmplx.Abs(root-want) < 1e-9 {
+				found = true
+			}
+		}
+		if !found {
+			t.Fatalf("root %v not found in %v", want, roots)
+		}
+	}
+	for _, root := range roots {
+		if cmplx.Abs(poly.Evaluate(root)) > 1e-9 {
+			t.Fatalf("p(%v) = %v, expected 0", root, poly.Evaluate(root))
+		}
+	}
+	if _, err := (Polynomial{0, 0}).Roots(); !errors.Is(err, ErrInvalidOrder) {
+		t.Fatalf("expected ErrInvalidOrder for the zero polynomial, got %v", err)
+	}
+
+	// seasonal AR(1)(1)_4, the product (1 - 0.5B)(1 - 0.3B^4)
+	config, err := NewConfig(1, 0, 1, 1, 0, 0, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.setParamsFromVector(matrix.NewInsightVectorWithData([]float64{0.5, 0.3, 0.4}, false))
+	ar := config.ARPolynomial()
+	if fmt.Sprint(ar) != "[1 -0.5 0 0 -0.3 0.15]" {
+		t.Fatalf("unexpected AR polynomial %v", ar)
+	}
+	if ma := config.MAPolynomial(); fmt.Sprint(ma) != "[1 0.4]" {
+		t.Fatalf("unexpected MA polynomial %v", ma)
+	}
+	model := &Model{Params: config}
+	arRoots, err := model.ARRoots()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, root := range arRoots {
+		if cmplx.Abs(root) <= 1 || cmplx.Abs(ar.Evaluate(root)) > 1e-9 {
+			t.Fatalf("unexpected AR root %v", root)
+		}
+	}
+	maRoots, err := model.MARoots()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(maRoots) != 1 || cmplx.Abs(maRoots[0]+2.5) > 1e-12 {
+		t.Fatalf("expected MA root -2.5, got %v", maRoots)
+	}
+}
+
+func TestCorrelations(t *testing.T) {
+	data := generateARMA(2000, 0.7, 0, 13)
+	acf, err := ACF(data, CorrelationOptions{MaxLag: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(acf.Values) != 6 || acf.Lags[0] != 0 || acf.Values[0] != 1 {
+		t.Fatalf("unexpected ACF %v at lags %v", acf.Values, acf.Lags)
+	}
+	for k := 1; k <= 5; k++ {
+		if math.Abs(acf.Values[k]-math.Pow(0.7, float64(k))) > 0.06 {
+			t.Fatalf("expected ACF 0.7^%d at lag %d, got %v", k, k, acf.Values[k])
+		}
+	}
+	if math.Abs(acf.Bounds[1]-1.959964/math.Sqrt(2000)) > 1e-6 {
+		t.Fatalf("unexpected white-noise bound %v", acf.Bounds[1])
+	}
+	bartlett, err := ACF(data, CorrelationOptions{MaxLag: 5, Bounds: BoundsBartlett})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(bartlett.Bounds[1]-acf.Bounds[1]) > 1e-12 || bartlett.Bounds[3] <= bartlett.Bounds[2] {
+		t.Fatalf("expected Bartlett bounds to widen with the lag, got %v", bartlett.Bounds)
+	}
+
+	pacf, err := PACF(data, CorrelationOptions{MaxLag: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pacf.Lags[0] != 1 || math.Abs(pacf.Values[0]-acf.Values[1]) > 1e-12 {
+		t.Fatalf("expected PACF at lag 1 to equal the ACF, got %v", pacf.Values[0])
+	}
+	for k := 1; k < 5; k++ {
+		if math.Abs(pacf.Values[k]) > 3*pacf.Bounds[k] {
+			t.Fatalf("expected negligible PACF of an AR(1) at lag %d, got %v", k+1, pacf.Values[k])
+		}
+	}
+	// the last partial autocorrelation is the last Yule-Walker coefficient
+	yuleWalker, err := FitYuleWalker(data, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uncentred, err := PACF(data, CorrelationOptions{MaxLag: 3, NoDemean: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(uncentred.Values[2]-yuleWalker[2]) > 1e-9 {
+		t.Fatalf("expected PACF %v to match Yule-Walker %v", uncentred.Values[2], yuleWalker[2])
+	}
+
+	// x[t] = y[t+3], so x[t+k] matches y[t] at k = -3
+	x := data[3:]
+	y := data[:len(data)-3]
+	ccf, err := CCF(x, y, CorrelationOptions{MaxLag: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ccf.Values) != 9 || ccf.Lags[0] != -4 {
+		t.Fatalf("unexpected CCF lags %v", ccf.Lags)
+	}
+	if math.Abs(ccf.Values[4-3]-acf.Values[0]) > 0.01 || math.Abs(ccf.Values[4]-acf.Values[3]) > 0.01 {
+		t.Fatalf("expected CCF to be the shifted ACF, got %v", ccf.Values)
+	}
+
+	if _, err := ACF([]float64{1, 1, 1}, CorrelationOptions{}); !errors.Is(err, ErrSingularSystem) {
+		t.Fatalf("expected ErrSingularSystem for a constant series, got %v", err)
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	data := generateARMA(600, 0.6, 0.3, 17)
+	arma, err := NewConfig(1, 0, 1, 0, 0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	model, err := Fit(data, arma, FitOptions{Method: MethodML})
+	if err != nil {
+		t.Fatal(err)
+	}
+	diagnostics, err := model.Diagnostics(DiagnosticsOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diagnostics.LjungBox.Lags != 10 || diagnostics.LjungBox.DF != 8 {
+		t.Fatalf("expected 10 lags on 8 df, got %d on %d", diagnostics.LjungBox.Lags, diagnostics.LjungBox.DF)
+	}
+	if diagnostics.LjungBox.PValue < 0.01 || diagnostics.BoxPierce.PValue < 0.01 {
+		t.Fatalf("correct model rejected: Ljung-Box p=%v, Box-Pierce p=%v",
+			diagnostics.LjungBox.PValue, diagnostics.BoxPierce.PValue)
+	}
+	if diagnostics.JarqueBera.PValue < 0.01 || diagnostics.ARCHLM.PValue < 0.01 {
+		t.Fatalf("Gaussian homoskedastic errors rejected: Jarque-Bera p=%v, ARCH-LM p=%v",
+			diagnostics.JarqueBera.PValue, diagnostics.ARCHLM.PValue)
+	}
+
+	whiteNoise, err := NewConfig(0, 0, 0, 0, 0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	model, err = Fit(data, whiteNoise, FitOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	diagnostics, err = model.Diagnostics(DiagnosticsOptions{Lags: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diagnostics.LjungBox.DF != 5 || diagnostics.LjungBox.PValue > 1e-6 {
+		t.Fatalf("expected white noise model to be rejected, got p=%v on %d df",
+			diagnostics.LjungBox.PValue, diagnostics.LjungBox.DF)
+	}
+}
+
+func TestFittedValues(t *testing.T) {
+	diffs := generateARMA(300, 0.5, 0, 19)
+	data := make([]float64, len(diffs)+1)
+	data[0] = 10
+	for i, d := range diffs {
+		data[i+1] = data[i] + d
+	}
+	config, err := NewConfig(1, 1, 0, 0, 0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	model, err := Fit(data, config, FitOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ar, _ := model.GetParams().armaCoefficients()
+	fitted, residuals := model.FittedValues(), model.Residuals()
+	if len(fitted) != len(data) || len(residuals) != len(data) {
+		t.Fatalf("expected %d fitted values and residuals, got %d and %d", len(data), len(fitted), len(residuals))
+	}
+	// one point is lost to differencing and one to the AR lag
+	if !math.IsNaN(fitted[0]) || !math.IsNaN(fitted[1]) || math.IsNaN(fitted[2]) {
+		t.Fatalf("expected the first two fitted values to be NaN, got %v", fitted[:3])
+	}
+	for i := 2; i < len(data); i++ {
+		want := data[i-1] + ar[0]*(data[i-1]-data[i-2])
+		if math.Abs(fitted[i]-want) > 1e-9 || math.Abs(fitted[i]+residuals[i]-data[i]) > 1e-9 {
+			t.Fatalf("point %d: expected fitted value %v, got %v", i, want, fitted[i])
+		}
+	}
+
+	// a regression adds X beta back
+	temperature := make([]float64, len(data))
+	for i := range temperature {
+		temperature[i] = math.Sin(float64(i) / 7)
+		data[i] += 2 * temperature[i]
+	}
+	for _, method := range []Method{MethodHannanRissanen, MethodML} {
+		model, err = Fit(data, config, FitOptions{Method: method, Xreg: [][]float64{temperature}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		fitted, residuals = model.FittedValues(), model.Residuals()
+		for i := 2; i < len(data); i++ {
+			if math.Abs(fitted[i]+residuals[i]-data[i]) > 1e-9 {
+				t.Fatalf("method %d, point %d: fitted value %v and residual %v do not add up to %v",
+					method, i, fitted[i], residuals[i], data[i])
+			}
+		}
+		if math.Abs(residuals[len(data)-1]) > 5 {
+			t.Fatalf("method %d: implausible residual %v", method, residuals[len(data)-1])
+		}
+	}
+}
+
 var cscchris_val = []float64{
 	2674.8060304978917, 3371.1788109723193, 2657.161969121835, 2814.5583226655367, 3290.855749923403, 3103.622791045206, 3403.2011487950185, 2841.438925235243, 2995.312700153925, 3256.4042898633224, 2609.8702933486843, 3214.6409110870877, 2952.1736018157644, 3468.7045537306344, 3260.9227206904898, 2645.5024256492215, 3137.857549381811, 3311.3526531674556, 2929.7762119375716, 2846.05991810631, 2606.47822546165, 3174.9770937667918, 3140.910443979614, 2590.6601484185085, 3123.4299821259915, 2714.4060964141136, 3133.9561758319487, 2951.3288157912752, 2860.3114228342765, 2757.4279640677833}
 var cscchris_answer = []float64{
 	3147.816496825682, 3418.2300802476093, 2856.905414401418, 3419.0312162705545, 3307.9803365878442, 3527.68377555284}
 
+func TestBoxCox(t *testing.T) {
+	logs := generateARMA(200, 0.6, 0, 23)
+	data := make([]float64, len(logs))
+	for i, v := range logs {
+		data[i] = math.Exp(3 + 0.3*v)
+	}
+	config, err := NewConfig(1, 0, 0, 0, 0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	onLogs, err := boxcox.Apply(data, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reference, err := Fit(onLogs, config, FitOptions{Method: MethodML})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := reference.Forecast(12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	model, err := Fit(data, config, FitOptions{Method: MethodML, BoxCox: &boxcox.Transform{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := model.Forecast(12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range result.Forecast {
+		if math.Abs(result.Forecast[i]-math.Exp(expected.Forecast[i])) > 1e-9 ||
+			math.Abs(result.GetForecastUpperConf()[i]-math.Exp(expected.GetForecastUpperConf()[i])) > 1e-9 ||
+			math.Abs(result.GetForecastLowerConf()[i]-math.Exp(expected.GetForecastLowerConf()[i])) > 1e-9 {
+			t.Fatalf("step %d: forecast is not the back-transformed log forecast", i)
+		}
+	}
+
+	// the bias-adjusted forecast is the lognormal mean
+	model, err = Fit(data, config, FitOptions{Method: MethodML, BoxCox: &boxcox.Transform{BiasAdjust: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	adjusted, err := model.Forecast(12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	z := levelToConstant(defaultConfidenceLevel)
+	for i := range adjusted.Forecast {
+		sd := (expected.GetForecastUpperConf()[i] - expected.Forecast[i]) / z
+		want := math.Exp(expected.Forecast[i]) * (1 + sd*sd/2)
+		if math.Abs(adjusted.Forecast[i]-want) > 1e-9 || adjusted.Forecast[i] <= result.Forecast[i] {
+			t.Fatalf("step %d: expected bias-adjusted forecast %v, got %v", i, want, adjusted.Forecast[i])
+		}
+		if adjusted.GetForecastUpperConf()[i] != result.GetForecastUpperConf()[i] {
+			t.Fatalf("step %d: bias adjustment should not move the interval", i)
+		}
+	}
+
+	fitted, residuals := model.FittedValues(), model.Residuals()
+	for i := 1; i < len(data); i++ {
+		if math.Abs(fitted[i]+residuals[i]-data[i]) > 1e-9 {
+			t.Fatalf("point %d: fitted value %v and residual %v do not add up to %v", i, fitted[i], residuals[i], data[i])
+		}
+	}
+
+	// an automatic lambda is resolved once and reported
+	model, err = Fit(data, config, FitOptions{BoxCox: &boxcox.Transform{Auto: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transform, ok := model.BoxCox(); !ok || transform.Auto || math.Abs(transform.Lambda) > 0.5 {
+		t.Fatalf("expected a resolved lambda near 0, got %+v", transform)
+	}
+	if _, err = Fit([]float64{1, 2, -3, 4, 5, 6}, config, FitOptions{BoxCox: &boxcox.Transform{}}); !errors.Is(err, boxcox.ErrNonPositive) {
+		t.Fatalf("expected ErrNonPositive, got %v", err)
+	}
+}
+
+func TestMissingValues(t *testing.T) {
+	diffs := generateARMA(300, 0.6, 0, 29)
+	complete := make([]float64, len(diffs)+1)
+	complete[0] = 50
+	for i, d := range diffs {
+		complete[i+1] = complete[i] + d
+	}
+	data := append([]float64(nil), complete...)
+	for _, i := range []int{0, 40, 41, 120, 250} {
+		data[i] = math.NaN()
+	}
+	config, err := NewConfig(1, 1, 0, 0, 0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, method := range []Method{MethodHannanRissanen, MethodML, MethodCSS, MethodCSSML} {
+		model, err := Fit(data, config, FitOptions{Method: method})
+		if err != nil {
+			t.Fatalf("method %d: %v", method, err)
+		}
+		if model.NumMissing() != 5 {
+			t.Fatalf("method %d: expected 5 missing values, got %d", method, model.NumMissing())
+		}
+		if ar, _ := model.GetParams().armaCoefficients(); math.Abs(ar[0]-0.6) > 0.15 {
+			t.Fatalf("method %d: expected AR coefficient near 0.6, got %v", method, ar[0])
+		}
+		if math.IsNaN(model.LogLikelihood()) || math.IsNaN(model.Sigma2()) || math.IsNaN(model.RMSE) {
+			t.Fatalf("method %d: missing values leaked into the likelihood", method)
+		}
+		result, err := model.Forecast(10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !isFinite(result.Forecast) || !isFinite(result.GetForecastUpperConf()) {
+			t.Fatalf("method %d: non-finite forecast %v", method, result.Forecast)
+		}
+		if fitted := model.FittedValues(); !math.IsNaN(fitted[120]) || math.IsNaN(fitted[200]) {
+			t.Fatalf("method %d: expected NaN fitted values only at missing points", method)
+		}
+	}
+
+	// the Kalman filter skips missing values: every observation but the
+	// missing ones and the one lost to differencing enters the likelihood
+	model, err := Fit(data, config, FitOptions{Method: MethodML})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// each missing level removes two differences, which overlap at 40 and 41
+	if model.NumObs() != len(data)-1-8 {
+		t.Fatalf("expected %d observations, got %d", len(data)-9, model.NumObs())
+	}
+	reference, err := Fit(complete, config, FitOptions{Method: MethodML})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := reference.Forecast(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := model.Forecast(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range result.Forecast {
+		if math.Abs(result.Forecast[i]-expected.Forecast[i]) > 0.2 {
+			t.Fatalf("step %d: forecast %v is far from the complete-data forecast %v",
+				i, result.Forecast[i], expected.Forecast[i])
+		}
+	}
+
+	// a missing last value is imputed with its prediction before
+	// forecasting, so the next forecast is two steps from the last
+	// observation
+	data[len(data)-1] = math.NaN()
+	if model, err = Fit(data, config, FitOptions{Method: MethodML}); err != nil {
+		t.Fatal(err)
+	}
+	if result, err = model.Forecast(1); err != nil || !isFinite(result.Forecast) {
+		t.Fatalf("unexpected forecast %v, %v", result, err)
+	}
+	n := len(data)
+	imputed := imputeMissing(model.Params, model.data)[n-1]
+	if math.IsNaN(imputed) || math.Abs(imputed-complete[n-1]) > 4*math.Sqrt(model.Sigma2()) {
+		t.Fatalf("imputed %v for the last value, observed %v", imputed, complete[n-1])
+	}
+	twoStep, err := forecastARIMA(model.Params, model.data, n-1, n+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(twoStep.Forecast[0]-imputed) > 1e-9 || math.Abs(twoStep.Forecast[1]-result.Forecast[0]) > 1e-9 {
+		t.Fatalf("forecast %v from the imputed %v, expected %v", result.Forecast[0], imputed, twoStep.Forecast)
+	}
+	if model, err = Fit(data, config, FitOptions{IncludeDrift: Include}); err != nil {
+		t.Fatal(err)
+	}
+	if result, err = model.Forecast(1); err != nil || !isFinite(result.Forecast) {
+		t.Fatalf("unexpected forecast with drift %v, %v", result, err)
+	}
+	if _, err = Fit([]float64{math.NaN(), math.NaN(), math.NaN()}, config, FitOptions{}); !errors.Is(err, ErrInsufficientData) {
+		t.Fatalf("expected ErrInsufficientData, got %v", err)
+	}
+	if _, err = Fit([]float64{1, math.Inf(1), 3, 4}, config, FitOptions{}); !errors.Is(err, ErrNonFiniteInput) {
+		t.Fatalf("expected ErrNonFiniteInput, got %v", err)
+	}
+	if _, _, err = Auto(data, 0, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Hannan-Rissanen needs complete rows of lags
+	sparse := append([]float64(nil), complete...)
+	for i := 1; i < len(sparse); i += 2 {
+		sparse[i] = math.NaN()
+	}
+	arma, err := NewConfig(2, 0, 1, 0, 0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = Fit(sparse, arma, FitOptions{}); !errors.Is(err, ErrInsufficientData) {
+		t.Fatalf("expected ErrInsufficientData for alternating missing values, got %v", err)
+	}
+}
+
+func TestSubsetARIMA(t *testing.T) {
+	rng := rand.New(rand.NewSource(31))
+	data := make([]float64, 600)
+	for i := range data {
+		data[i] = rng.NormFloat64()
+		if i >= 1 {
+			data[i] += 0.5 * data[i-1]
+		}
+		if i >= 12 {
+			data[i] += 0.3 * data[i-12]
+		}
+	}
+	config, err := NewSubsetConfig(Lags{Lags: []int{12, 1}}, 0, Lags{}, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, method := range []Method{MethodHannanRissanen, MethodCSS, MethodML} {
+		model, err := Fit(data, config, FitOptions{Method: method, IncludeMean: Exclude})
+		if err != nil {
+			t.Fatalf("method %d: %v", method, err)
+		}
+		if model.NumParams() != 3 {
+			t.Fatalf("method %d: expected 2 coefficients and sigma^2, got %d parameters", method, model.NumParams())
+		}
+		coefficients, err := model.Coefficients()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(coefficients) != 2 || coefficients[0].Name != "ar1" || coefficients[1].Name != "ar12" {
+			t.Fatalf("method %d: unexpected coefficients %+v", method, coefficients)
+		}
+		if math.Abs(coefficients[0].Value-0.5) > 0.1 || math.Abs(coefficients[1].Value-0.3) > 0.1 {
+			t.Fatalf("method %d: expected coefficients near 0.5 and 0.3, got %+v", method, coefficients)
+		}
+		if _, err := model.Forecast(24); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// a fixed coefficient is kept and not counted
+	config, err = NewSubsetConfig(Lags{Lags: []int{1, 12}, Fixed: map[int]float64{12: 0.3}}, 0, Lags{Lags: []int{1}}, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, method := range []Method{MethodHannanRissanen, MethodCSS, MethodML} {
+		model, err := Fit(data, config, FitOptions{Method: method, IncludeMean: Exclude})
+		if err != nil {
+			t.Fatalf("method %d: %v", method, err)
+		}
+		ar, ma := model.GetParams().armaCoefficients()
+		if len(ar) != 12 || ar[11] != 0.3 || ar[1] != 0 {
+			t.Fatalf("method %d: unexpected AR coefficients %v", method, ar)
+		}
+		if math.Abs(ar[0]-0.5) > 0.15 || math.Abs(ma[0]) > 0.15 {
+			t.Fatalf("method %d: expected ar1 near 0.5 and ma1 near 0, got %v and %v", method, ar[0], ma[0])
+		}
+		if model.NumParams() != 3 {
+			t.Fatalf("method %d: expected ar1, ma1 and sigma^2, got %d parameters", method, model.NumParams())
+		}
+	}
+
+	// a non-stationary fixed part cannot be damped
+	config, err = NewSubsetConfig(Lags{Lags: []int{1, 2}, Fixed: map[int]float64{2: 1.2}}, 0, Lags{}, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Fit(data, config, FitOptions{}); !errors.Is(err, ErrInadmissible) {
+		t.Fatalf("expected ErrInadmissible, got %v", err)
+	}
+	if _, err := NewSubsetConfig(Lags{Lags: []int{1}, Fixed: map[int]float64{2: 0.1}}, 0, Lags{}, 0, 0); !errors.Is(err, ErrInvalidOrder) {
+		t.Fatalf("expected ErrInvalidOrder, got %v", err)
+	}
+	if _, err := NewSubsetConfig(Lags{Lags: []int{1, 1}}, 0, Lags{}, 0, 0); !errors.Is(err, ErrInvalidOrder) {
+		t.Fatalf("expected ErrInvalidOrder, got %v", err)
+	}
+}
+
+func TestPredictionIntervalWidths(t *testing.T) {
+	for _, c := range []struct {
+		ar, ma, want []float64
+	}{
+		{[]float64{0.5}, nil, []float64{1, 0.5, 0.25, 0.125}},
+		{[]float64{0.5}, []float64{0.4}, []float64{1, 0.9, 0.45, 0.225}},
+		{[]float64{1.5, -0.5}, nil, []float64{1, 1.5, 1.75, 1.875}},
+	} {
+		psi := ARMAtoMA(c.ar, c.ma, len(c.want))
+		for i := range psi {
+			if math.Abs(psi[i]-c.want[i]) > 1e-12 {
+				t.Fatalf("ARMAtoMA(%v, %v) = %v, expected %v", c.ar, c.ma, psi, c.want)
+			}
+		}
+	}
+
+	// the psi-weights of ARIMA(1,1,0) include the differencing operator
+	config, err := NewConfig(1, 1, 0, 0, 0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.setParamsFromVector(matrix.NewInsightVectorWithData([]float64{0.5}, false))
+	psi := config.psiWeights(4)
+	for i, want := range []float64{1, 1.5, 1.75, 1.875} {
+		if math.Abs(psi[i]-want) > 1e-12 {
+			t.Fatalf("expected psi-weights 1, 1.5, 1.75, 1.875, got %v", psi)
+		}
+	}
+
+	// a random walk has interval half-widths z sigma sqrt(h)
+	rng := rand.New(rand.NewSource(37))
+	data := make([]float64, 400)
+	for i := 1; i < len(data); i++ {
+		data[i] = data[i-1] + rng.NormFloat64()
+	}
+	config, err = NewConfig(0, 1, 0, 0, 0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	model, err := Fit(data, config, FitOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := model.Forecast(16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	z := levelToConstant(defaultConfidenceLevel)
+	for h := 1; h <= 16; h++ {
+		want := z * math.Sqrt(model.Sigma2()*float64(h))
+		if got := result.GetForecastUpperConf()[h-1] - result.Forecast[h-1]; math.Abs(got-want) > 1e-9 {
+			t.Fatalf("step %d: expected half-width %v, got %v", h, want, got)
+		}
+	}
+	if math.Abs(model.Sigma2()-1) > 0.2 {
+		t.Fatalf("expected residual variance near 1, got %v", model.Sigma2())
+	}
+}
+
+func TestSimulate(t *testing.T) {
+	config, err := NewConfig(1, 0, 1, 0, 0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	process := Process{AR: []float64{0.6}, MA: []float64{0.3}, Sigma: 2}
+	data, err := Simulate(config, process, 2000, 100, rand.NewSource(41))
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := Simulate(config, process, 2000, 100, rand.NewSource(41))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range data {
+		if data[i] != again[i] {
+			t.Fatalf("point %d: the same seed gave %v and %v", i, data[i], again[i])
+		}
+	}
+	model, err := Fit(data, config, FitOptions{Method: MethodML})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ar, ma := model.GetParams().armaCoefficients()
+	if math.Abs(ar[0]-0.6) > 0.05 || math.Abs(ma[0]-0.3) > 0.05 || math.Abs(model.Sigma2()-4) > 0.4 {
+		t.Fatalf("expected (0.6, 0.3, 4), recovered (%v, %v, %v)", ar[0], ma[0], model.Sigma2())
+	}
+
+	// seasonal differencing: the seasonal differences are white noise
+	config, err = NewConfig(0, 0, 0, 0, 1, 0, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err = Simulate(config, Process{}, 400, 0, rand.NewSource(43))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 400 {
+		t.Fatalf("expected 400 points, got %d", len(data))
+	}
+	acf, err := ACF(difference(data, 4), CorrelationOptions{MaxLag: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k := 1; k <= 8; k++ {
+		if math.Abs(acf.Values[k]) > 3*acf.Bounds[k] {
+			t.Fatalf("lag %d: seasonal differences are autocorrelated, %v", k, acf.Values[k])
+		}
+	}
+	if _, err = Simulate(config, Process{AR: []float64{0.5}}, 10, 0, rand.NewSource(1)); !errors.Is(err, ErrInvalidOrder) {
+		t.Fatalf("expected ErrInvalidOrder, got %v", err)
+	}
+	config, _ = NewConfig(1, 0, 0, 0, 0, 0, 0)
+	if _, err = Simulate(config, Process{AR: []float64{1.1}}, 10, 0, rand.NewSource(1)); !errors.Is(err, ErrInadmissible) {
+		t.Fatalf("expected ErrInadmissible, got %v", err)
+	}
+
+	// simulated futures of a random walk spread like the prediction interval
+	config, _ = NewConfig(0, 1, 0, 0, 0, 0, 0)
+	walk, err := Simulate(config, Process{}, 300, 0, rand.NewSource(47))
+	if err != nil {
+		t.Fatal(err)
+	}
+	model, err = Fit(walk, config, FitOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths, err := model.SimulateFuture(9, 4000, rand.NewSource(53))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := model.Forecast(9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for h := 0; h < 9; h++ {
+		outside := 0
+		for _, path := range paths {
+			if path[h] > result.GetForecastUpperConf()[h] || path[h] < result.GetForecastLowerConf()[h] {
+				outside++
+			}
+		}
+		if share := float64(outside) / float64(len(paths)); math.Abs(share-0.05) > 0.015 {
+			t.Fatalf("step %d: %v of the paths leave the 95%% interval", h+1, share)
+		}
+	}
+}
+
 func dbl2str(value float64) string {
 	return fmt.Sprintf("%.5f", value)
 }
@@ -47,7 +1334,16 @@ func dbl2str(value float64) string {
 func commonTestCalculateRMSE(name string, trainingData []float64, trueForecastData []float64, forecastSize int, p, d, q, P, D, Q, m int) float64 {
 
 	// Make forecast
-	forecastResult := ForeCastARIMA(trainingData, forecastSize, NewConfig(p, d, q, P, D, Q, m))
+	config, err := NewConfig(p, d, q, P, D, Q, m)
+	if err != nil {
+		log.Debug(err)
+		return math.NaN()
+	}
+	forecastResult, err := ForeCastARIMA(trainingData, forecastSize, config)
+	if err != nil {
+		log.Debug(err)
+		return math.NaN()
+	}
 	//Get forecast data and confidence intervals
 	forecast := forecastResult.GetForecast()
 	upper := forecastResult.GetForecastUpperConf()
@@ -101,3 +1397,392 @@ func commonTestCalculateRMSE(name string, trainingData []float64, trueForecastDa
 	log.Debug(sb.String())
 	return rmse
 }
+
+// TestConcurrentForecast forecasts a fitted model from several goroutines;
+// run with -race to check that forecasting does not write to the model.
+func TestConcurrentForecast(t *testing.T) {
+	config, err := NewConfig(1, 1, 1, 1, 1, 0, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := Simulate(config, Process{AR: []float64{0.5, 0.3}, MA: []float64{0.2}}, 120, 50, rand.NewSource(71))
+	if err != nil {
+		t.Fatal(err)
+	}
+	model, err := Fit(data, config, FitOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := model.Forecast(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	results := make([]*Result, 8)
+	errs := make([]error, len(results))
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = model.Forecast(10)
+		}(i)
+	}
+	wg.Wait()
+	for i, result := range results {
+		if errs[i] != nil {
+			t.Fatal(errs[i])
+		}
+		for h, v := range result.Forecast {
+			if v != expected.Forecast[h] {
+				t.Fatalf("goroutine %d, step %d: forecast %v, expected %v", i, h, v, expected.Forecast[h])
+			}
+		}
+	}
+}
+
+func TestForecastBootstrap(t *testing.T) {
+	// AR(1) with contaminated normal innovations: light shoulders, heavy tails
+	rng := rand.New(rand.NewSource(47))
+	data := make([]float64, 1500)
+	for i := 1; i < len(data); i++ {
+		e := rng.NormFloat64()
+		if rng.Float64() < 0.1 {
+			e *= 5
+		}
+		data[i] = 0.5*data[i-1] + e
+	}
+	config, err := NewConfig(1, 0, 0, 0, 0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	model, err := Fit(data, config, FitOptions{Method: MethodCSS})
+	if err != nil {
+		t.Fatal(err)
+	}
+	levels := []float64{0.8, 0.99}
+	normal, err := model.ForecastInterval(3, levels)
+	if err != nil {
+		t.Fatal(err)
+	}
+	boot, err := model.ForecastBootstrap(3, levels, distribution.BootstrapOptions{Paths: 4000, Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range boot.Forecast {
+		if boot.Forecast[i] != normal.Forecast[i] {
+			t.Fatalf("point %d: bootstrap changed the forecast", i)
+		}
+	}
+	width := func(r *Result, level float64) float64 {
+		return r.GetForecastUpperConfAt(level)[0] - r.GetForecastLowerConfAt(level)[0]
+	}
+	if width(boot, 0.99) <= 1.3*width(normal, 0.99) {
+		t.Fatalf("99%% bootstrap width %v should exceed the normal %v", width(boot, 0.99), width(normal, 0.99))
+	}
+	if width(boot, 0.8) >= width(normal, 0.8) {
+		t.Fatalf("80%% bootstrap width %v should be below the normal %v", width(boot, 0.8), width(normal, 0.8))
+	}
+	for i := range boot.Forecast {
+		lower80, upper80 := boot.GetForecastLowerConfAt(0.8)[i], boot.GetForecastUpperConfAt(0.8)[i]
+		lower99, upper99 := boot.GetForecastLowerConfAt(0.99)[i], boot.GetForecastUpperConfAt(0.99)[i]
+		if !(lower99 < lower80 && lower80 < boot.Forecast[i] && boot.Forecast[i] < upper80 && upper80 < upper99) {
+			t.Fatalf("point %d: bounds not nested around the forecast", i)
+		}
+	}
+	if boot.GetForecastUpperConf()[0] != boot.GetForecastUpperConfAt(0.8)[0] {
+		t.Fatal("the first level should populate GetForecastUpperConf")
+	}
+
+	again, err := model.ForecastBootstrap(3, levels, distribution.BootstrapOptions{Paths: 4000, Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.GetForecastUpperConfAt(0.99)[2] != boot.GetForecastUpperConfAt(0.99)[2] {
+		t.Fatal("the same seed gave different intervals")
+	}
+	if _, err := model.ForecastBootstrap(3, levels, distribution.BootstrapOptions{Paths: -1}); !errors.Is(err, ErrInvalidOrder) {
+		t.Fatalf("expected ErrInvalidOrder for negative paths, got %v", err)
+	}
+}
+
+func TestForecastDistribution(t *testing.T) {
+	config, err := NewConfig(1, 1, 0, 0, 0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := Simulate(config, Process{AR: []float64{0.4}}, 300, 50, rand.NewSource(53))
+	if err != nil {
+		t.Fatal(err)
+	}
+	model, err := Fit(data, config, FitOptions{Method: MethodML})
+	if err != nil {
+		t.Fatal(err)
+	}
+	levels := []float64{0.8, 0.95}
+	result, err := model.ForecastInterval(5, levels)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forecastDistribution, err := model.ForecastDistribution(5, []float64{0.025, 0.1, 0.5, 0.9, 0.975})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for h := 0; h < 5; h++ {
+		if math.Abs(forecastDistribution.Mean[h]-result.Forecast[h]) > 1e-9 {
+			t.Fatalf("step %d: mean %v, forecast %v", h, forecastDistribution.Mean[h], result.Forecast[h])
+		}
+		if math.Abs(forecastDistribution.Quantiles[4][h]-result.GetForecastUpperConfAt(0.95)[h]) > 1e-9 ||
+			math.Abs(forecastDistribution.Quantiles[1][h]-result.GetForecastLowerConfAt(0.8)[h]) > 1e-9 {
+			t.Fatalf("step %d: quantiles do not match the prediction intervals", h)
+		}
+	}
+	if p, _ := forecastDistribution.Exceedance(4, result.GetForecastUpperConfAt(0.95)[4]); math.Abs(p-0.025) > 1e-6 {
+		t.Fatalf("exceedance of the upper 95%% bound: got %v", p)
+	}
+
+	boot, err := model.BootstrapDistribution(5, []float64{0.5}, distribution.BootstrapOptions{Paths: 2000, Seed: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(boot.Paths) != 2000 || boot.Horizon() != 5 {
+		t.Fatalf("expected 2000 paths of 5 points, got %d of %d", len(boot.Paths), boot.Horizon())
+	}
+	if sd := math.Sqrt(forecastDistribution.Variance[4]); math.Abs(boot.Mean[4]-result.Forecast[4]) > 0.15*sd ||
+		math.Abs(math.Sqrt(boot.Variance[4])-sd) > 0.15*sd {
+		t.Fatalf("bootstrap moments (%v, %v) far from the normal (%v, %v)",
+			boot.Mean[4], boot.Variance[4], result.Forecast[4], forecastDistribution.Variance[4])
+	}
+
+	// Box-Cox: quantiles back-transform, the mean is bias-adjusted
+	positive := make([]float64, len(data))
+	for i, v := range data {
+		positive[i] = math.Exp(v / 10)
+	}
+	logModel, err := Fit(positive, config, FitOptions{BoxCox: &boxcox.Transform{Lambda: 0, BiasAdjust: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logResult, err := logModel.ForecastInterval(3, []float64{0.95})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logDistribution, err := logModel.ForecastDistribution(3, []float64{0.025, 0.975})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(logDistribution.Quantiles[1][2]-logResult.GetForecastUpperConf()[2]) > 1e-9 ||
+		math.Abs(logDistribution.Mean[2]-logResult.Forecast[2]) > 1e-9 || !math.IsNaN(logDistribution.Variance[2]) {
+		t.Fatalf("Box-Cox distribution does not match the forecast")
+	}
+	if p, _ := logDistribution.Exceedance(2, -1); p != 1 {
+		t.Fatalf("a positive forecast exceeds -1 surely, got %v", p)
+	}
+}
+
+func TestAppend(t *testing.T) {
+	config, err := NewConfig(1, 1, 1, 0, 0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := Simulate(config, Process{AR: []float64{0.5}, MA: []float64{0.3}}, 300, 50, rand.NewSource(59))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, method := range []Method{MethodHannanRissanen, MethodML} {
+		model, err := Fit(data[:250], config, FitOptions{Method: method})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ar, ma := model.GetParams().armaCoefficients()
+		for _, y := range data[250:] {
+			if err := model.Append(y); err != nil {
+				t.Fatal(err)
+			}
+		}
+		arAfter, maAfter := model.GetParams().armaCoefficients()
+		for i := range ar {
+			if arAfter[i] != ar[i] || maAfter[i] != ma[i] {
+				t.Fatalf("method %d: Append changed the coefficients", method)
+			}
+		}
+		if model.Refits() != 0 || len(model.Residuals()) != 300 {
+			t.Fatalf("method %d: expected no refit and 300 residuals, got %d and %d",
+				method, model.Refits(), len(model.Residuals()))
+		}
+		result, err := model.Forecast(5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, err := forecastARIMA(model.Params, data, len(data), len(data)+5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for h := range expected.Forecast {
+			if math.Abs(result.Forecast[h]-expected.Forecast[h]) > 1e-9 {
+				t.Fatalf("method %d, step %d: forecast %v, expected %v from the full data",
+					method, h, result.Forecast[h], expected.Forecast[h])
+			}
+		}
+	}
+
+	// the incremental update matches a full pass over the extended data,
+	// with seasonal differencing and missing values
+	seasonal, err := NewConfig(1, 1, 1, 1, 1, 0, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seasonalData, err := Simulate(seasonal, Process{AR: []float64{0.5, 0.3}, MA: []float64{0.2}}, 200, 50, rand.NewSource(67))
+	if err != nil {
+		t.Fatal(err)
+	}
+	seasonalData[185], seasonalData[192] = math.NaN(), math.NaN()
+	for _, method := range []Method{MethodHannanRissanen, MethodCSS, MethodML} {
+		model, err := Fit(seasonalData[:180], seasonal, FitOptions{Method: method})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, y := range seasonalData[180:] {
+			if err := model.Append(y); err != nil {
+				t.Fatal(err)
+			}
+		}
+		stationary, err := differenceWith(model.Params, model.data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		exact, ok := model.Params.exactFilter(stationary, nil)
+		if !ok {
+			t.Fatalf("method %d: fitted coefficients are not admissible", method)
+		}
+		logLik, sigma2 := exact.logLikelihood()
+		if math.Abs(model.LogLikelihood()-logLik) > 1e-8 || math.Abs(model.Sigma2()-sigma2) > 1e-10 ||
+			model.NumObs() != exact.nobs {
+			t.Fatalf("method %d: incremental likelihood (%v, %v, %d), full pass (%v, %v, %d)", method,
+				model.LogLikelihood(), model.Sigma2(), model.NumObs(), logLik, sigma2, exact.nobs)
+		}
+		residuals := computeResiduals(model.Params, stationary)
+		if method == MethodML {
+			residuals = exact.standardizedResiduals()
+		}
+		for i, e := range residuals {
+			if !(math.IsNaN(e) && math.IsNaN(model.residuals[i])) && math.Abs(e-model.residuals[i]) > 1e-9 {
+				t.Fatalf("method %d, point %d: incremental residual %v, full pass %v", method, i, model.residuals[i], e)
+			}
+		}
+	}
+
+	// the drift continues from the new origin
+	walk, err := NewConfig(0, 1, 0, 0, 0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rng := rand.New(rand.NewSource(61))
+	line := make([]float64, 120)
+	for i := range line {
+		line[i] = 2*float64(i) + rng.NormFloat64()
+	}
+	model, err := Fit(line[:100], walk, FitOptions{IncludeDrift: Include})
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot := *model
+	before, err := snapshot.Forecast(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := model.Append(line[100:]...); err != nil {
+		t.Fatal(err)
+	}
+	// a copy taken before appending keeps its own drift origin
+	if after, err := snapshot.Forecast(2); err != nil || after.Forecast[0] != before.Forecast[0] {
+		t.Fatalf("appending to the model moved the forecast of its copy: %v, %v", after, err)
+	}
+	result, err := model.Forecast(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	drift := model.regression.beta[0]
+	if math.Abs(result.Forecast[0]-(line[119]+drift)) > 1e-9 || math.Abs(result.Forecast[1]-(line[119]+2*drift)) > 1e-9 {
+		t.Fatalf("expected %v + k*%v, got %v", line[119], drift, result.Forecast)
+	}
+
+	// a refit every 10 points re-estimates on everything seen so far
+	model, err = Fit(data[:250], config, FitOptions{Method: MethodCSS, Refit: RefitPolicy{Every: 10}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, y := range data[250:275] {
+		if err := model.Append(y); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if model.Refits() != 2 {
+		t.Fatalf("expected 2 refits, got %d", model.Refits())
+	}
+	full, err := Fit(data[:270], config, FitOptions{Method: MethodCSS})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := full.Append(data[270:275]...); err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(model.Sigma2()-full.Sigma2()) > 1e-6 {
+		t.Fatalf("residual variance after appending %v, refitted %v", model.Sigma2(), full.Sigma2())
+	}
+
+	// a jump in the residual scale triggers a refit
+	model, err = Fit(data[:250], config, FitOptions{Refit: RefitPolicy{Window: 10, ScaleRatio: 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := data[249]
+	for i := 0; i < 9; i++ {
+		last += 10 * rng.NormFloat64()
+		if err := model.Append(last); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if model.Refits() != 0 {
+		t.Fatal("refitted before the window was full")
+	}
+	if err := model.Append(last + 10*rng.NormFloat64()); err != nil {
+		t.Fatal(err)
+	}
+	if model.Refits() != 1 {
+		t.Fatalf("expected a refit on the scale drift, got %d", model.Refits())
+	}
+
+	// missing observations pass through the Box-Cox transform
+	positive := make([]float64, 250)
+	for i := range positive {
+		positive[i] = math.Exp(data[i] / 10)
+	}
+	logModel, err := Fit(positive, config, FitOptions{BoxCox: &boxcox.Transform{Lambda: 0}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := logModel.Append(math.NaN()); err != nil {
+		t.Fatalf("appending a missing value: %v", err)
+	}
+	if err := logModel.Append(math.NaN(), 1.5); err != nil {
+		t.Fatal(err)
+	}
+	if logModel.NumMissing() != 2 || !math.IsNaN(logModel.data[250]) || logModel.data[252] != math.Log(1.5) {
+		t.Fatalf("expected two missing values and the log of 1.5 last, got %d missing, %v",
+			logModel.NumMissing(), logModel.data[250:])
+	}
+	if result, err := logModel.Forecast(3); err != nil || !isFinite(result.Forecast) {
+		t.Fatalf("unexpected forecast %v, %v", result, err)
+	}
+
+	if err := model.Append(math.Inf(1)); !errors.Is(err, ErrNonFiniteInput) {
+		t.Fatalf("expected ErrNonFiniteInput, got %v", err)
+	}
+	withRegressors, err := Fit(line[:100], walk, FitOptions{Xreg: [][]float64{data[:100]}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := withRegressors.Append(1); !errors.Is(err, ErrInvalidRegressors) {
+		t.Fatalf("expected ErrInvalidRegressors, got %v", err)
+	}
+}
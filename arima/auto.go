@@ -0,0 +1,421 @@
+package arima
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/DoOR-Team/timeseries_forecasting/arima/tests"
+	"github.com/DoOR-Team/timeseries_forecasting/boxcox"
+)
+
+const maxStepsStepwise = 94
+
+// Criterion selects the information criterion Auto ranks candidates by.
+type Criterion int
+
+const (
+	CriterionAICc Criterion = iota
+	CriterionAIC
+	CriterionBIC
+)
+
+// AutoOptions bounds the order search performed by Auto. The zero value
+// selects the defaults, those of DefaultAutoOptions.
+type AutoOptions struct {
+	// MaxP, MaxQ, MaxSeasonalP and MaxSeasonalQ bound the orders searched.
+	// Zero selects the default and a negative bound excludes the term.
+	MaxP, MaxQ                 int
+	MaxSeasonalP, MaxSeasonalQ int
+	// MaxOrder bounds p+q+P+Q, in the same way.
+	MaxOrder int
+	// D and SeasonalD fix the differencing orders; nil selects them from
+	// the data, bounded by MaxD and MaxSeasonalD, which default like the
+	// other bounds. A chosen d is never more than 2.
+	D, SeasonalD       *int
+	MaxD, MaxSeasonalD int
+	// Exhaustive fits every admissible order instead of following the
+	// Hyndman-Khandakar stepwise search.
+	Exhaustive bool
+	Criterion  Criterion
+	// Alpha is the significance level of the KPSS test that chooses d,
+	// clamped to [0.01, 0.1]. Defaults to 0.05.
+	Alpha float64
+	// SeasonalTest chooses D. Defaults to the seasonal strength heuristic.
+	SeasonalTest tests.SeasonalTest
+	FitOptions   FitOptions
+}
+
+// DefaultAutoOptions returns the bounds used by auto.arima in R's forecast
+// package.
+func DefaultAutoOptions() AutoOptions {
+	return AutoOptions{
+		MaxP:         5,
+		MaxQ:         5,
+		MaxSeasonalP: 2,
+		MaxSeasonalQ: 2,
+		MaxOrder:     5,
+		MaxD:         2,
+		MaxSeasonalD: 1,
+		Criterion:    CriterionAICc,
+		Alpha:        0.05,
+	}
+}
+
+// withDefaults returns o with its zero bounds replaced by the defaults and
+// its negative ones by 0.
+func (o AutoOptions) withDefaults() AutoOptions {
+	defaults := DefaultAutoOptions()
+	for _, bound := range []struct{ value, def *int }{
+		{&o.MaxP, &defaults.MaxP},
+		{&o.MaxQ, &defaults.MaxQ},
+		{&o.MaxSeasonalP, &defaults.MaxSeasonalP},
+		{&o.MaxSeasonalQ, &defaults.MaxSeasonalQ},
+		{&o.MaxOrder, &defaults.MaxOrder},
+		{&o.MaxD, &defaults.MaxD},
+		{&o.MaxSeasonalD, &defaults.MaxSeasonalD},
+	} {
+		if *bound.value == 0 {
+			*bound.value = *bound.def
+		} else if *bound.value < 0 {
+			*bound.value = 0
+		}
+	}
+	if o.Alpha == 0 {
+		o.Alpha = defaults.Alpha
+	}
+	return o
+}
+
+// Candidate is one model considered by Auto. Constant reports whether it
+// includes a mean (d+D = 0) or drift (d+D = 1). Err is set if it failed to
+// fit.
+type Candidate struct {
+	Spec     Config
+	Constant bool
+	AIC      float64
+	AICc     float64
+	BIC      float64
+	Err      error
+}
+
+func (c Candidate) score(criterion Criterion) float64 {
+	if c.Err != nil {
+		return math.Inf(1)
+	}
+	var s float64
+	switch criterion {
+	case CriterionAIC:
+		s = c.AIC
+	case CriterionBIC:
+		s = c.BIC
+	default:
+		s = c.AICc
+	}
+	if math.IsNaN(s) {
+		return math.Inf(1)
+	}
+	return s
+}
+
+// Auto selects and fits an ARIMA model for data with seasonal period m
+// (m < 2 for non-seasonal data). The differencing orders are chosen first
+// with unit-root tests, then p, q, P and Q are searched within the bounds of
+// opts (nil selects the defaults) and ranked by the chosen information
+// criterion, which for every estimator uses the exact likelihood over the
+// same differenced sample. Missing values (NaN) are dropped for the unit-root tests,
+// except that the seasonal test uses the longest stretch without any, and
+// are handled by Fit otherwise. When d+D <= 1 the search also decides on a mean or drift
+// term, unless opts.FitOptions fixes it. A Box-Cox transform in
+// opts.FitOptions is resolved once and applied before choosing the
+// differencing orders. It returns the best fitted model and every
+// candidate tried.
+func Auto(data []float64, m int, opts *AutoOptions) (*Model, []Candidate, error) {
+	if opts == nil {
+		opts = &AutoOptions{}
+	}
+	if (opts.D != nil && *opts.D < 0) || (opts.SeasonalD != nil && *opts.SeasonalD < 0) || m < 0 {
+		return nil, nil, fmt.Errorf("%w: differencing orders and period must be non-negative", ErrInvalidOrder)
+	}
+	resolvedOpts := opts.withDefaults()
+	opts = &resolvedOpts
+	missing, ok := countMissing(data)
+	if !ok {
+		return nil, nil, ErrNonFiniteInput
+	}
+	if missing == len(data) {
+		return nil, nil, fmt.Errorf("%w: every observation is missing", ErrInsufficientData)
+	}
+	alpha := opts.Alpha
+	maxSeasonalP, maxSeasonalQ := opts.MaxSeasonalP, opts.MaxSeasonalQ
+	if m < 2 {
+		m = 0
+		maxSeasonalP, maxSeasonalQ = 0, 0
+	}
+
+	// every candidate shares the transform, so their criteria compare
+	transformed := data
+	if opts.FitOptions.BoxCox != nil {
+		resolved, err := opts.FitOptions.BoxCox.Resolve(data, m)
+		if err != nil {
+			return nil, nil, err
+		}
+		if transformed, err = boxcox.Apply(data, resolved.Lambda); err != nil {
+			return nil, nil, err
+		}
+		opts.FitOptions.BoxCox = &resolved
+	}
+
+	var seasonalD int
+	if opts.SeasonalD != nil {
+		seasonalD = *opts.SeasonalD
+	} else {
+		seasonalD = chooseSeasonalD(longestObservedRun(transformed), m, opts.MaxSeasonalD, opts.SeasonalTest)
+	}
+	if m == 0 {
+		seasonalD = 0
+	}
+	seasonallyDifferenced := transformed
+	for i := 0; i < seasonalD; i++ {
+		seasonallyDifferenced = difference(seasonallyDifferenced, m)
+	}
+	var d int
+	if opts.D != nil {
+		d = *opts.D
+	} else {
+		var err error
+		if d, err = chooseD(observedValues(seasonallyDifferenced), opts.MaxD, alpha); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	search := &orderSearch{
+		data:      data,
+		d:         d,
+		seasonalD: seasonalD,
+		m:         m,
+		opts:      opts,
+		fitted:    make(map[[5]int]int),
+	}
+	// the constant is a mean without differencing and a drift with one
+	constantOption := opts.FitOptions.IncludeMean
+	if d+seasonalD == 1 {
+		constantOption = opts.FitOptions.IncludeDrift
+	}
+	switch {
+	case d+seasonalD > 1 || constantOption == Exclude:
+		search.minConstant, search.maxConstant = 0, 0
+	case constantOption == Include:
+		search.minConstant, search.maxConstant = 1, 1
+	default:
+		search.minConstant, search.maxConstant = 0, 1
+	}
+	bounds := [5]int{opts.MaxP, opts.MaxQ, maxSeasonalP, maxSeasonalQ, search.maxConstant}
+	if opts.Exhaustive {
+		search.exhaustive(bounds)
+	} else {
+		search.stepwise(bounds)
+	}
+
+	if search.best == nil {
+		return nil, search.candidates, fmt.Errorf("no candidate model could be fitted: %w", search.lastErr)
+	}
+	return search.best, search.candidates, nil
+}
+
+type orderSearch struct {
+	data         []float64
+	d, seasonalD int
+	m            int
+	opts         *AutoOptions
+
+	// bounds of the constant flag, the last element of an order
+	minConstant, maxConstant int
+
+	candidates []Candidate
+	fitted     map[[5]int]int // order (p, q, P, Q, constant) -> index into candidates
+	best       *Model
+	bestOrder  [5]int
+	bestScore  float64
+	lastErr    error
+}
+
+// try fits the given (p, q, P, Q, constant) order unless it was already
+// tried and reports whether it improved on the best model so far.
+func (s *orderSearch) try(order [5]int) bool {
+	if _, ok := s.fitted[order]; ok {
+		return false
+	}
+	candidate := Candidate{Constant: order[4] == 1}
+	fitOptions := s.opts.FitOptions
+	fitOptions.IncludeMean, fitOptions.IncludeDrift = Exclude, Exclude
+	if candidate.Constant {
+		if s.d+s.seasonalD == 0 {
+			fitOptions.IncludeMean = Include
+		} else {
+			fitOptions.IncludeDrift = Include
+		}
+	}
+	spec, err := NewConfig(order[0], s.d, order[1], order[2], s.seasonalD, order[3], s.m)
+	var model *Model
+	if err == nil {
+		model, err = Fit(s.data, spec, fitOptions)
+	}
+	candidate.Spec = spec
+	if err != nil {
+		candidate.Err = err
+		s.lastErr = err
+	} else {
+		candidate.AIC, candidate.AICc, candidate.BIC = model.AIC(), model.AICc(), model.BIC()
+	}
+	s.fitted[order] = len(s.candidates)
+	s.candidates = append(s.candidates, candidate)
+
+	score := candidate.score(s.opts.Criterion)
+	if math.IsInf(score, 1) || (s.best != nil && score >= s.bestScore) {
+		return false
+	}
+	s.best = model
+	s.bestOrder = order
+	s.bestScore = score
+	return true
+}
+
+func (s *orderSearch) admissible(order, bounds [5]int) bool {
+	sum := 0
+	for i := 0; i < 4; i++ {
+		if order[i] < 0 || order[i] > bounds[i] {
+			return false
+		}
+		sum += order[i]
+	}
+	return order[4] >= s.minConstant && order[4] <= s.maxConstant && sum <= s.opts.MaxOrder
+}
+
+// stepwise implements the Hyndman-Khandakar search: start from a few
+// simple models and move to a neighbouring order while that improves the
+// criterion.
+func (s *orderSearch) stepwise(bounds [5]int) {
+	starts := [][5]int{{2, 2, 1, 1, 1}, {0, 0, 0, 0, 1}, {1, 0, 1, 0, 1}, {0, 1, 0, 1, 1}, {0, 0, 0, 0, 0}}
+	for _, start := range starts {
+		for i := range start {
+			if start[i] > bounds[i] {
+				start[i] = bounds[i]
+			}
+		}
+		if start[4] < s.minConstant {
+			start[4] = s.minConstant
+		}
+		if s.admissible(start, bounds) {
+			s.try(start)
+		}
+	}
+	if s.best == nil {
+		return
+	}
+
+	moves := [][5]int{
+		{-1, 0, 0, 0, 0}, {1, 0, 0, 0, 0}, {0, -1, 0, 0, 0}, {0, 1, 0, 0, 0},
+		{0, 0, -1, 0, 0}, {0, 0, 1, 0, 0}, {0, 0, 0, -1, 0}, {0, 0, 0, 1, 0},
+		{-1, -1, 0, 0, 0}, {1, 1, 0, 0, 0}, {0, 0, -1, -1, 0}, {0, 0, 1, 1, 0},
+		{0, 0, 0, 0, -1}, {0, 0, 0, 0, 1},
+	}
+	for step := 0; step < maxStepsStepwise; step++ {
+		improved := false
+		for _, move := range moves {
+			next := s.bestOrder
+			for i := range next {
+				next[i] += move[i]
+			}
+			if s.admissible(next, bounds) && s.try(next) {
+				improved = true
+				break
+			}
+		}
+		if !improved {
+			return
+		}
+	}
+}
+
+// exhaustive fits every admissible order within bounds.
+func (s *orderSearch) exhaustive(bounds [5]int) {
+	for p := 0; p <= bounds[0]; p++ {
+		for q := 0; q <= bounds[1]; q++ {
+			for P := 0; P <= bounds[2]; P++ {
+				for Q := 0; Q <= bounds[3]; Q++ {
+					for constant := s.minConstant; constant <= s.maxConstant; constant++ {
+						order := [5]int{p, q, P, Q, constant}
+						if s.admissible(order, bounds) {
+							s.try(order)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// chooseD returns the number of differences recommended by the KPSS test
+// at significance alpha, at most maxD.
+func chooseD(data []float64, maxD int, alpha float64) (int, error) {
+	d, err := tests.NDiffs(data, tests.KPSSTest, alpha)
+	if err != nil {
+		return 0, err
+	}
+	if d > maxD {
+		d = maxD
+	}
+	return d, nil
+}
+
+// chooseSeasonalD returns the number of seasonal differences recommended
+// by test, or 0 if the series is too short to tell.
+func chooseSeasonalD(data []float64, m, maxSeasonalD int, test tests.SeasonalTest) int {
+	if m < 2 || maxSeasonalD < 1 {
+		return 0
+	}
+	seasonalD, err := tests.NSDiffs(data, m, test)
+	if err != nil {
+		return 0
+	}
+	return seasonalD
+}
+
+// difference returns data[t] - data[t-lag].
+func difference(data []float64, lag int) []float64 {
+	if len(data) <= lag {
+		return nil
+	}
+	diff := make([]float64, len(data)-lag)
+	for t := lag; t < len(data); t++ {
+		diff[t-lag] = data[t] - data[t-lag]
+	}
+	return diff
+}
+
+// observedValues returns data without its missing values.
+func observedValues(data []float64) []float64 {
+	observed := make([]float64, 0, len(data))
+	for _, v := range data {
+		if !math.IsNaN(v) {
+			observed = append(observed, v)
+		}
+	}
+	return observed
+}
+
+// longestObservedRun returns the longest stretch of data without missing
+// values, the first one if there are several.
+func longestObservedRun(data []float64) []float64 {
+	bestStart, bestEnd, start := 0, 0, 0
+	for t := 0; t <= len(data); t++ {
+		if t < len(data) && !math.IsNaN(data[t]) {
+			continue
+		}
+		if t-start > bestEnd-bestStart {
+			bestStart, bestEnd = start, t
+		}
+		start = t + 1
+	}
+	return data[bestStart:bestEnd]
+}
@@ -2,8 +2,6 @@ package arima
 
 import (
 	"fmt"
-
-	"github.com/DoOR-Team/goutils/log"
 )
 
 type BackShift struct {
@@ -13,9 +11,9 @@ type BackShift struct {
 	_coeffs  []float64
 }
 
-func NewBackShift(degree int, initial bool) *BackShift {
+func NewBackShift(degree int, initial bool) (*BackShift, error) {
 	if degree < 0 {
-		log.Fatal("degree must be non-negative")
+		return nil, fmt.Errorf("%w: degree must be non-negative, got %d", ErrInvalidOrder, degree)
 	}
 	b := &BackShift{
 		_degree:  degree,
@@ -27,7 +25,7 @@ func NewBackShift(degree int, initial bool) *BackShift {
 		b._indices[j] = initial
 	}
 	b._indices[0] = true // zero index must be true all the time
-	return b
+	return b, nil
 }
 
 func (b *BackShift) getDegree() int {
@@ -0,0 +1,60 @@
+package arima
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/DoOR-Team/timeseries_forecasting/distribution"
+)
+
+// ForecastBootstrap forecasts h points like ForecastInterval, but the
+// prediction intervals at levels (95% if none is given) are empirical
+// quantiles of future paths simulated with innovations resampled from the
+// centred in-sample residuals. The intervals keep the shape of residuals
+// that are not normal, e.g. heavy-tailed, at the cost of Monte Carlo
+// noise. Models with exogenous regressors are not supported.
+func (m *Model) ForecastBootstrap(h int, levels []float64, opts distribution.BootstrapOptions) (*Result, error) {
+	if len(levels) == 0 {
+		levels = []float64{defaultConfidenceLevel}
+	}
+	forecastResult, err := m.ForecastInterval(h, levels)
+	if err != nil {
+		return nil, err
+	}
+	samples, err := m.bootstrapPaths(h, opts)
+	if err != nil {
+		return nil, err
+	}
+	forecastResult.setEmpiricalIntervals(levels, samples)
+	return forecastResult, nil
+}
+
+// bootstrapPaths simulates future paths of h points with innovations
+// resampled from the centred residuals.
+func (m *Model) bootstrapPaths(h int, opts distribution.BootstrapOptions) ([][]float64, error) {
+	paths, err := opts.NumPaths()
+	if err != nil {
+		return nil, fmt.Errorf("%w: number of bootstrap paths must be positive, got %d", ErrInvalidOrder, opts.Paths)
+	}
+	residuals := make([]float64, 0, len(m.residuals))
+	mean := 0.0
+	for _, e := range m.residuals {
+		if !math.IsNaN(e) {
+			residuals = append(residuals, e)
+			mean += e
+		}
+	}
+	if len(residuals) == 0 {
+		return nil, fmt.Errorf("%w: no residuals to resample", ErrInsufficientData)
+	}
+	mean /= float64(len(residuals))
+	for i := range residuals {
+		residuals[i] -= mean
+	}
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+	return m.samplePaths(h, paths, func() float64 {
+		return residuals[rng.Intn(len(residuals))]
+	})
+}
@@ -0,0 +1,183 @@
+package arima
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/DoOR-Team/timeseries_forecasting/arima/matrix"
+	"github.com/DoOR-Team/timeseries_forecasting/arima/utils"
+)
+
+// Coefficient is an estimated model coefficient with its uncertainty.
+// Names follow R: ar1, ma2, sar1 (seasonal AR at lag m), sma1, xreg1 for
+// exogenous regressors, drift and mean. The coefficients of a subset model
+// are named by their lag, e.g. ar24.
+type Coefficient struct {
+	Name   string
+	Lag    int
+	Value  float64
+	StdErr float64
+	TStat  float64
+	PValue float64 // two-sided, from the normal approximation
+}
+
+// Coefficients returns the estimated AR, MA, regression and mean
+// coefficients with standard errors taken from the numerical Hessian of
+// the objective the model was estimated with (the exact likelihood for
+// MethodML and MethodCSSML, the conditional sum of squares otherwise).
+func (m *Model) Coefficients() ([]Coefficient, error) {
+	covariance, err := m.CovarianceMatrix()
+	if err != nil {
+		return nil, err
+	}
+	names, lags := m.coefficientNames()
+	values := m.coefficientValues()
+	coefficients := make([]Coefficient, len(values))
+	for i, value := range values {
+		stdErr := math.NaN()
+		if covariance[i][i] >= 0 {
+			stdErr = math.Sqrt(covariance[i][i])
+		}
+		tStat := value / stdErr
+		coefficients[i] = Coefficient{
+			Name:   names[i],
+			Lag:    lags[i],
+			Value:  value,
+			StdErr: stdErr,
+			TStat:  tStat,
+			PValue: 2 * (1 - utils.NormalCDF(math.Abs(tStat))),
+		}
+	}
+	return coefficients, nil
+}
+
+// Coefficient returns the coefficient with the given name, e.g. "ar1".
+func (m *Model) Coefficient(name string) (Coefficient, bool, error) {
+	coefficients, err := m.Coefficients()
+	if err != nil {
+		return Coefficient{}, false, err
+	}
+	for _, c := range coefficients {
+		if c.Name == name {
+			return c, true, nil
+		}
+	}
+	return Coefficient{}, false, nil
+}
+
+// CovarianceMatrix returns the estimated covariance matrix of the
+// coefficients, in the order of Coefficients.
+func (m *Model) CovarianceMatrix() ([][]float64, error) {
+	if m.covariance != nil {
+		return m.covariance, nil
+	}
+	values := m.coefficientValues()
+	covariance := newSquare(len(values))
+	if len(values) > 0 {
+		scratch, err := m.Params.clone()
+		if err != nil {
+			return nil, err
+		}
+		var objective func([]float64) float64
+		switch {
+		case m.regression != nil:
+			objective = m.regression.jointObjective(scratch, m.method)
+		case m.method == MethodML || m.method == MethodCSSML:
+			objective = exactObjective(m.stationary, scratch)
+		default:
+			objective = cssObjective(m.stationary, scratch)
+		}
+		hessian := numericHessian(objective, values)
+		n := float64(len(m.stationary))
+		for i := range hessian {
+			for j := range hessian[i] {
+				hessian[i][j] *= n
+			}
+		}
+		if covariance, err = invertSymmetric(hessian); err != nil {
+			return nil, err
+		}
+	}
+	m.covariance = covariance
+	return covariance, nil
+}
+
+func (m *Model) coefficientValues() []float64 {
+	var values []float64
+	if m.Params.getNumParamsP()+m.Params.getNumParamsQ() > 0 {
+		estimates := m.Params.getParamsIntoVector()
+		values = append(values, estimates.DeepCopy()...)
+	}
+	if m.regression != nil {
+		values = append(values, m.regression.beta...)
+	}
+	return values
+}
+
+func (m *Model) coefficientNames() (names []string, lags []int) {
+	c := m.Params
+	if c.isSubset() {
+		for _, lag := range c.getOffsetsAR() {
+			names = append(names, fmt.Sprintf("ar%d", lag))
+			lags = append(lags, lag)
+		}
+		for _, lag := range c.getOffsetsMA() {
+			names = append(names, fmt.Sprintf("ma%d", lag))
+			lags = append(lags, lag)
+		}
+	} else {
+		for _, factor := range []struct {
+			prefix  string
+			n, step int
+		}{
+			{"ar", len(c.nonSeasonalAR), 1},
+			{"sar", len(c.seasonalAR), c.m},
+			{"ma", len(c.nonSeasonalMA), 1},
+			{"sma", len(c.seasonalMA), c.m},
+		} {
+			for i := 1; i <= factor.n; i++ {
+				names = append(names, fmt.Sprintf("%s%d", factor.prefix, i))
+				lags = append(lags, i*factor.step)
+			}
+		}
+	}
+	if r := m.regression; r != nil {
+		for j := range r.xreg {
+			names = append(names, fmt.Sprintf("xreg%d", j+1))
+			lags = append(lags, 0)
+		}
+		if r.drift {
+			names = append(names, "drift")
+			lags = append(lags, 0)
+		}
+		if r.intercept {
+			names = append(names, "mean")
+			lags = append(lags, 0)
+		}
+	}
+	return names, lags
+}
+
+// invertSymmetric inverts a symmetric matrix through the LDL'
+// decomposition of the matrix package.
+func invertSymmetric(a [][]float64) ([][]float64, error) {
+	n := len(a)
+	data := newSquare(n)
+	for i := range a {
+		copy(data[i], a[i])
+	}
+	decomposed := matrix.NewInsightsMatrixWithData(data, false)
+	inverse := newSquare(n)
+	for j := 0; j < n; j++ {
+		unit := make([]float64, n)
+		unit[j] = 1
+		column := decomposed.SolveSPDIntoVector(matrix.NewInsightVectorWithData(unit, false), -1)
+		if column == nil {
+			return nil, fmt.Errorf("%w: coefficient Hessian is singular", ErrSingularSystem)
+		}
+		for i := 0; i < n; i++ {
+			inverse[i][j] = column.Get(i)
+		}
+	}
+	return inverse, nil
+}
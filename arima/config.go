@@ -2,6 +2,7 @@ package arima
 
 import (
 	"fmt"
+	"math"
 
 	"github.com/DoOR-Team/timeseries_forecasting/arima/matrix"
 	"github.com/DoOR-Team/timeseries_forecasting/arima/utils"
@@ -20,15 +21,29 @@ type Config struct {
 	initNonSeasonal      [][]float64
 	diffNonSeasonal      [][]float64
 	integrateNonSeasonal [][]float64
-	lagsAR               []int
-	paramsAR             []float64
-	lagsMA               []int
-	paramsMA             []float64
+	// lags of a subset model and their fixed coefficients, NaN where
+	// estimated; nil for contiguous orders
+	lagsAR   []int
+	paramsAR []float64
+	lagsMA   []int
+	paramsMA []float64
+	// coefficients of the non-seasonal and seasonal factors of the AR and
+	// MA polynomials, indexed by lag-1 and seasonal lag-1; opAR and opMA
+	// hold their product. nil for a subset model
+	nonSeasonalAR []float64
+	seasonalAR    []float64
+	nonSeasonalMA []float64
+	seasonalMA    []float64
 	// I part
 	mean float64
 }
 
-func NewConfig(p, d, q, P, D, Q, m int) Config {
+func NewConfig(p, d, q, P, D, Q, m int) (Config, error) {
+	if p < 0 || d < 0 || q < 0 || P < 0 || D < 0 || Q < 0 || m < 0 {
+		return Config{}, fmt.Errorf(
+			"%w: orders must be non-negative, got p=%d, d=%d, q=%d, P=%d, D=%d, Q=%d, m=%d",
+			ErrInvalidOrder, p, d, q, P, D, Q, m)
+	}
 	config := Config{
 		p:                    p,
 		d:                    d,
@@ -53,49 +68,83 @@ func NewConfig(p, d, q, P, D, Q, m int) Config {
 		paramsAR:             nil,
 		lagsMA:               nil,
 		paramsMA:             nil,
+		nonSeasonalAR:        make([]float64, p),
+		nonSeasonalMA:        make([]float64, q),
 		mean:                 0,
 	}
+	if m > 0 {
+		config.seasonalAR = make([]float64, P)
+		config.seasonalMA = make([]float64, Q)
+	}
 
-	config.opAR = config.getNewOperatorAR()
-	config.opMA = config.getNewOperatorAR()
+	var err error
+	if config.opAR, err = config.getNewOperatorAR(); err != nil {
+		return Config{}, err
+	}
+	if config.opMA, err = config.getNewOperatorMA(); err != nil {
+		return Config{}, err
+	}
 
 	config.opAR.initializeParams(false)
 	config.opMA.initializeParams(false)
 	config.dp = config.opAR.getDegree()
 	config.dq = config.opMA.getDegree()
-	config.np = config.opAR.numParams()
-	config.nq = config.opMA.numParams()
-	if D > 0 && m > 0 {
-		config.initSeasonal = make([][]float64, D)
-		for i, _ := range config.initSeasonal {
-			config.initSeasonal[i] = make([]float64, m)
+	config.np = len(config.nonSeasonalAR) + len(config.seasonalAR)
+	config.nq = len(config.nonSeasonalMA) + len(config.seasonalMA)
+	config.allocateBuffers()
+	return config, nil
+}
+
+// allocateBuffers gives c new differencing and integration buffers.
+func (c *Config) allocateBuffers() {
+	c.initSeasonal, c.diffSeasonal, c.integrateSeasonal = nil, nil, nil
+	c.initNonSeasonal, c.diffNonSeasonal, c.integrateNonSeasonal = nil, nil, nil
+	if c.D > 0 && c.m > 0 {
+		c.initSeasonal = make([][]float64, c.D)
+		for i, _ := range c.initSeasonal {
+			c.initSeasonal[i] = make([]float64, c.m)
 		}
 	}
 
-	if d > 0 {
-		config.initNonSeasonal = make([][]float64, d)
-		for i, _ := range config.initNonSeasonal {
-			config.initNonSeasonal[i] = make([]float64, 1)
+	if c.d > 0 {
+		c.initNonSeasonal = make([][]float64, c.d)
+		for i, _ := range c.initNonSeasonal {
+			c.initNonSeasonal[i] = make([]float64, 1)
 		}
 	}
 
-	if D > 0 && m > 0 {
-		config.diffSeasonal = make([][]float64, D)
+	if c.D > 0 && c.m > 0 {
+		c.diffSeasonal = make([][]float64, c.D)
 	}
 
-	if d > 0 {
-		config.diffNonSeasonal = make([][]float64, d)
+	if c.d > 0 {
+		c.diffNonSeasonal = make([][]float64, c.d)
 	}
 
-	if D > 0 && m > 0 {
-		config.integrateSeasonal = make([][]float64, D)
+	if c.D > 0 && c.m > 0 {
+		c.integrateSeasonal = make([][]float64, c.D)
 	}
 
-	if d > 0 {
-		config.integrateNonSeasonal = make([][]float64, d)
+	if c.d > 0 {
+		c.integrateNonSeasonal = make([][]float64, c.d)
 	}
+}
+
+// withOwnBuffers returns a copy of c that shares its coefficients but
+// differences and integrates into buffers of its own, so that concurrent
+// forecasts from one fitted model do not write to shared state.
+func (c Config) withOwnBuffers() Config {
+	c.allocateBuffers()
+	return c
+}
 
-	return config
+// clone returns a fresh, unestimated Config with the same orders as c,
+// keeping the lags and fixed coefficients of a subset model.
+func (c Config) clone() (Config, error) {
+	if c.isSubset() {
+		return NewSubsetConfig(subsetLags(c.lagsAR, c.paramsAR), c.d, subsetLags(c.lagsMA, c.paramsMA), c.D, c.m)
+	}
+	return NewConfig(c.p, c.d, c.q, c.P, c.D, c.Q, c.m)
 }
 
 func (c Config) getDegreeP() int {
@@ -122,11 +171,16 @@ func (c Config) getNumParamsQ() int {
 	return c.nq
 }
 
+// getOffsetsAR returns the lags of the merged AR operator whose
+// coefficients are not fixed, which the Hannan-Rissanen step regresses on.
 func (c Config) getOffsetsAR() []int {
-	return c.opAR.paramOffsets()
+	return freeOffsets(c.opAR, c.lagsAR, c.paramsAR)
 }
+
+// getOffsetsMA returns the lags of the merged MA operator whose
+// coefficients are not fixed.
 func (c Config) getOffsetsMA() []int {
-	return c.opMA.paramOffsets()
+	return freeOffsets(c.opMA, c.lagsMA, c.paramsMA)
 }
 
 func (c Config) getLastIntegrateSeasonal() []float64 {
@@ -146,6 +200,14 @@ func (c Config) getLastDifferenceNonSeasonal() []float64 {
 }
 
 func (c Config) String() string {
+	if c.isSubset() {
+		return fmt.Sprintf("ModelInterface ParamsInterface:"+
+			", AR lags= %v"+
+			", d= %d"+
+			", MA lags= %v"+
+			", D= %d"+
+			", m= %d", c.lagsAR, c.d, c.lagsMA, c.D, c.m)
+	}
 	return fmt.Sprintf("ModelInterface ParamsInterface:"+
 		", p= %d"+
 		", d= %d"+
@@ -156,24 +218,66 @@ func (c Config) String() string {
 		", m= %d", c.p, c.d, c.q, c.P, c.D, c.Q, c.m)
 }
 
+// setParamsFromVector sets the estimated coefficients from paramVec: the
+// non-seasonal AR, seasonal AR, non-seasonal MA and seasonal MA
+// coefficients, whose products make up the merged operators, or the free
+// lags of a subset model.
 func (c Config) setParamsFromVector(paramVec *matrix.InsightsVector) {
+	if c.isSubset() {
+		c.setLagCoefficients(paramVec)
+		return
+	}
 	index := 0
-	offsetsAR := c.getOffsetsAR()
-	offsetsMA := c.getOffsetsMA()
-	for _, pIdx := range offsetsAR {
+	for _, factor := range [][]float64{c.nonSeasonalAR, c.seasonalAR, c.nonSeasonalMA, c.seasonalMA} {
+		for i := range factor {
+			factor[i] = paramVec.Get(index)
+			index++
+		}
+	}
+	c.multiplyFactors()
+}
+
+func (c Config) getParamsIntoVector() matrix.InsightsVector {
+	if c.isSubset() {
+		return c.getLagCoefficients()
+	}
+	var values []float64
+	for _, factor := range [][]float64{c.nonSeasonalAR, c.seasonalAR, c.nonSeasonalMA, c.seasonalMA} {
+		values = append(values, factor...)
+	}
+	return *matrix.NewInsightVectorWithData(values, false)
+}
+
+// setLagCoefficients sets the coefficients of the merged operators at the
+// lags of getOffsetsAR followed by getOffsetsMA, as estimated by a linear
+// regression on lagged values. The factors of a seasonal model are read
+// off the non-seasonal and the pure seasonal lags, and the cross lags are
+// then set to their products.
+func (c Config) setLagCoefficients(paramVec *matrix.InsightsVector) {
+	index := 0
+	for _, pIdx := range c.getOffsetsAR() {
 		c.opAR.setParam(pIdx, paramVec.Get(index))
 		index++
 	}
-	for _, qIdx := range offsetsMA {
+	for _, qIdx := range c.getOffsetsMA() {
 		c.opMA.setParam(qIdx, paramVec.Get(index))
 		index++
 	}
+	if c.isSubset() {
+		return
+	}
+	readFactors(c.opAR, c.nonSeasonalAR, c.seasonalAR, c.m)
+	readFactors(c.opMA, c.nonSeasonalMA, c.seasonalMA, c.m)
+	c.multiplyFactors()
 }
-func (c Config) getParamsIntoVector() matrix.InsightsVector {
-	index := 0
-	paramVec := matrix.NewInsightVector(c.np+c.nq, 0.0)
+
+// getLagCoefficients returns the coefficients of the merged operators at
+// the lags of getOffsetsAR followed by getOffsetsMA.
+func (c Config) getLagCoefficients() matrix.InsightsVector {
 	offsetsAR := c.getOffsetsAR()
 	offsetsMA := c.getOffsetsMA()
+	paramVec := matrix.NewInsightVector(len(offsetsAR)+len(offsetsMA), 0.0)
+	index := 0
 	for _, pIdx := range offsetsAR {
 		paramVec.Set(index, c.opAR.getParam(pIdx))
 		index++
@@ -185,10 +289,61 @@ func (c Config) getParamsIntoVector() matrix.InsightsVector {
 	return paramVec
 }
 
-func (c Config) getNewOperatorAR() *BackShift {
+// multiplyFactors sets the merged operators to the products of the
+// non-seasonal and seasonal factors.
+func (c Config) multiplyFactors() {
+	setProduct(c.opAR, c.nonSeasonalAR, c.seasonalAR, c.m, -1)
+	setProduct(c.opMA, c.nonSeasonalMA, c.seasonalMA, c.m, 1)
+}
+
+// setProduct sets the coefficients of op to those of the product of
+// 1 + sign*(a_1 B + a_2 B^2 + ...) and 1 + sign*(s_1 B^m + s_2 B^2m + ...),
+// in the sign convention of the forecast recursion: sign is -1 for AR and
+// +1 for MA polynomials.
+func setProduct(op *BackShift, nonSeasonal, seasonal []float64, m int, sign float64) {
+	for j := range op._coeffs {
+		op._coeffs[j] = 0
+	}
+	add := func(lag int, value float64) {
+		op.setParam(lag, op.getParam(lag)+value)
+	}
+	for i, a := range nonSeasonal {
+		add(i+1, a)
+	}
+	for j, s := range seasonal {
+		add((j+1)*m, s)
+		for i, a := range nonSeasonal {
+			add(i+1+(j+1)*m, sign*a*s)
+		}
+	}
+}
+
+// readFactors sets the factors to the coefficients of op at lags 1, ..., p
+// and m, 2m, ..., Pm.
+func readFactors(op *BackShift, nonSeasonal, seasonal []float64, m int) {
+	for i := range nonSeasonal {
+		nonSeasonal[i] = op.getParam(i + 1)
+	}
+	for j := range seasonal {
+		seasonal[j] = op.getParam((j + 1) * m)
+	}
+}
+
+// scaleFactors multiplies the lag k coefficient of the factors by rho^k,
+// which scales their product the same way.
+func scaleFactors(nonSeasonal, seasonal []float64, m int, rho float64) {
+	for i := range nonSeasonal {
+		nonSeasonal[i] *= math.Pow(rho, float64(i+1))
+	}
+	for j := range seasonal {
+		seasonal[j] *= math.Pow(rho, float64((j+1)*m))
+	}
+}
+
+func (c Config) getNewOperatorAR() (*BackShift, error) {
 	return c.mergeSeasonalWithNonSeasonal(c.p, c.P, c.m)
 }
-func (c Config) getNewOperatorMA() *BackShift {
+func (c Config) getNewOperatorMA() (*BackShift, error) {
 	return c.mergeSeasonalWithNonSeasonal(c.q, c.Q, c.m)
 }
 
@@ -200,59 +355,100 @@ func (c Config) getCurrentMACoefficients() []float64 {
 	return c.opMA.getCoefficientsFlattened()
 }
 
-func (c Config) mergeSeasonalWithNonSeasonal(nonSeasonalLag, seasonalLag, seasonalStep int) *BackShift {
-	nonSeasonal := NewBackShift(nonSeasonalLag, true)
-	seasonal := NewBackShift(seasonalLag*seasonalStep, false)
+// armaCoefficients returns the AR and MA coefficients indexed by lag-1, so
+// ar[0] is the lag 1 coefficient.
+func (c Config) armaCoefficients() (ar, ma []float64) {
+	if flat := c.getCurrentARCoefficients(); len(flat) > 1 {
+		ar = flat[1:]
+	}
+	if flat := c.getCurrentMACoefficients(); len(flat) > 1 {
+		ma = flat[1:]
+	}
+	return
+}
+
+// mergeSeasonalWithNonSeasonal returns the operator of the product of the
+// non-seasonal and seasonal factors, with a coefficient at each of its
+// lags.
+func (c Config) mergeSeasonalWithNonSeasonal(nonSeasonalLag, seasonalLag, seasonalStep int) (*BackShift, error) {
+	nonSeasonal, err := NewBackShift(nonSeasonalLag, true)
+	if err != nil {
+		return nil, err
+	}
+	seasonal, err := NewBackShift(seasonalLag*seasonalStep, false)
+	if err != nil {
+		return nil, err
+	}
 	for s := 1; s <= seasonalLag; s++ {
 		seasonal.setIndex(s*seasonalStep, true)
 	}
 	merged := seasonal.apply(nonSeasonal)
-	return merged
+	return merged, nil
 }
 
 // ================================
 // Differentiation and Integration
 
-func (c Config) differentiateSeasonal(data []float64) {
+func (c Config) differentiateSeasonal(data []float64) error {
 	current := data
 	for j := 0; j < c.D; j++ {
+		if len(current) <= c.m {
+			return fmt.Errorf("%w: seasonal differencing needs more than %d points, have %d",
+				ErrInsufficientData, c.m, len(current))
+		}
 		next := make([]float64, len(current)-c.m)
 		c.diffSeasonal[j] = next
 		init := c.initSeasonal[j]
-		utils.Differentiate(current, next, init, c.m)
+		if err := utils.Differentiate(current, next, init, c.m); err != nil {
+			return err
+		}
 		current = next
 	}
+	return nil
 }
 
-func (c Config) differentiateNonSeasonal(data []float64) {
+func (c Config) differentiateNonSeasonal(data []float64) error {
 	current := data
 	for j := 0; j < c.d; j++ {
+		if len(current) <= 1 {
+			return fmt.Errorf("%w: differencing needs more than 1 point, have %d",
+				ErrInsufficientData, len(current))
+		}
 		next := make([]float64, len(current)-1)
 		c.diffNonSeasonal[j] = next
 		init := c.initNonSeasonal[j]
-		utils.Differentiate(current, next, init, 1)
+		if err := utils.Differentiate(current, next, init, 1); err != nil {
+			return err
+		}
 		current = next
 	}
+	return nil
 }
 
-func (c Config) getIntegrateSeasonal(data []float64) {
+func (c Config) getIntegrateSeasonal(data []float64) error {
 	current := data
 	for j := 0; j < c.D; j++ {
 		next := make([]float64, len(current)+c.m)
 		c.integrateSeasonal[j] = next
 		init := c.initSeasonal[j]
-		utils.Integrate(current, next, init, c.m)
+		if err := utils.Integrate(current, next, init, c.m); err != nil {
+			return err
+		}
 		current = next
 	}
+	return nil
 }
 
-func (c Config) getIntegrateNonSeasonal(data []float64) {
+func (c Config) getIntegrateNonSeasonal(data []float64) error {
 	current := data
 	for j := 0; j < c.d; j++ {
 		next := make([]float64, len(current)+1)
 		c.integrateNonSeasonal[j] = next
 		init := c.initNonSeasonal[j]
-		utils.Integrate(current, next, init, 1)
+		if err := utils.Integrate(current, next, init, 1); err != nil {
+			return err
+		}
 		current = next
 	}
+	return nil
 }
@@ -0,0 +1,246 @@
+package arima
+
+import (
+	"fmt"
+	"math"
+)
+
+// BoundMethod selects how Correlogram bounds are computed.
+type BoundMethod int
+
+const (
+	// BoundsWhiteNoise gives +-z/sqrt(n), the bounds under white noise.
+	BoundsWhiteNoise BoundMethod = iota
+	// BoundsBartlett widens the ACF bound at lag k with Bartlett's formula
+	// for an MA(k-1) process, sqrt((1 + 2 sum_{j<k} r_j^2)/n). PACF and CCF
+	// bounds are always white-noise bounds.
+	BoundsBartlett
+)
+
+// CorrelationOptions controls ACF, PACF and CCF. The zero value selects the
+// defaults.
+type CorrelationOptions struct {
+	// MaxLag is the largest lag computed. Defaults to 10*log10(n), or
+	// 10*log10(n/2) for CCF, and is capped at n-1.
+	MaxLag int
+	// NoDemean skips subtracting the sample mean, for series known to have
+	// a zero mean.
+	NoDemean bool
+	Bounds BoundMethod
+	// Level is the confidence level of the bounds. Defaults to 0.95.
+	Level float64
+}
+
+// Correlogram holds sample correlations by lag, with the half-width of a
+// confidence band around zero at each lag. Values outside the band are
+// significant at the chosen level.
+type Correlogram struct {
+	Lags   []int
+	Values []float64
+	Bounds []float64
+	Level  float64
+}
+
+// ACF returns the sample autocorrelations of data at lags 0 to MaxLag.
+func ACF(data []float64, opts CorrelationOptions) (*Correlogram, error) {
+	maxLag, level, err := opts.resolve(data, 1)
+	if err != nil {
+		return nil, err
+	}
+	acf, err := autocorrelations(data, maxLag, !opts.NoDemean)
+	if err != nil {
+		return nil, err
+	}
+	n := float64(len(data))
+	z := levelToConstant(level)
+	correlogram := newCorrelogram(0, acf, level)
+	squareSum := 0.0
+	for k := 1; k <= maxLag; k++ {
+		if opts.Bounds == BoundsBartlett {
+			correlogram.Bounds[k] = z * math.Sqrt((1+2*squareSum)/n)
+			squareSum += acf[k] * acf[k]
+		} else {
+			correlogram.Bounds[k] = z / math.Sqrt(n)
+		}
+	}
+	return correlogram, nil
+}
+
+// PACF returns the sample partial autocorrelations of data at lags 1 to
+// MaxLag, computed from the ACF by the Durbin-Levinson recursion.
+func PACF(data []float64, opts CorrelationOptions) (*Correlogram, error) {
+	maxLag, level, err := opts.resolve(data, 1)
+	if err != nil {
+		return nil, err
+	}
+	if maxLag < 1 {
+		return nil, fmt.Errorf("%w: PACF needs a positive maximum lag", ErrInvalidOrder)
+	}
+	acf, err := autocorrelations(data, maxLag, !opts.NoDemean)
+	if err != nil {
+		return nil, err
+	}
+	pacf := durbinLevinson(acf)
+	correlogram := newCorrelogram(1, pacf, level)
+	bound := levelToConstant(level) / math.Sqrt(float64(len(data)))
+	for k := range correlogram.Bounds {
+		correlogram.Bounds[k] = bound
+	}
+	return correlogram, nil
+}
+
+// CCF returns the sample cross-correlations of x[t+k] and y[t] at lags k
+// from -MaxLag to MaxLag. x and y must have the same length.
+func CCF(x, y []float64, opts CorrelationOptions) (*Correlogram, error) {
+	if len(x) != len(y) {
+		return nil, fmt.Errorf("CCF needs series of the same length, got %d and %d", len(x), len(y))
+	}
+	maxLag, level, err := opts.resolve(x, 2)
+	if err != nil {
+		return nil, err
+	}
+	if !isFinite(y) {
+		return nil, ErrNonFiniteInput
+	}
+	demean := !opts.NoDemean
+	xc, yc := centred(x, demean), centred(y, demean)
+	scale := math.Sqrt(dot(xc, xc) * dot(yc, yc))
+	if scale == 0 {
+		return nil, fmt.Errorf("%w: CCF of a constant series", ErrSingularSystem)
+	}
+	ccf := make([]float64, 2*maxLag+1)
+	for k := -maxLag; k <= maxLag; k++ {
+		sum := 0.0
+		for t := 0; t < len(y); t++ {
+			if t+k >= 0 && t+k < len(x) {
+				sum += xc[t+k] * yc[t]
+			}
+		}
+		ccf[k+maxLag] = sum / scale
+	}
+	correlogram := newCorrelogram(-maxLag, ccf, level)
+	bound := levelToConstant(level) / math.Sqrt(float64(len(x)))
+	for k := range correlogram.Bounds {
+		correlogram.Bounds[k] = bound
+	}
+	return correlogram, nil
+}
+
+// resolve validates data and opts and returns the maximum lag and level,
+// applying the defaults. numSeries is 2 for CCF.
+func (opts CorrelationOptions) resolve(data []float64, numSeries int) (maxLag int, level float64, err error) {
+	n := len(data)
+	if n < 2 {
+		return 0, 0, fmt.Errorf("%w: correlations need at least 2 points, have %d", ErrInsufficientData, n)
+	}
+	if !isFinite(data) {
+		return 0, 0, ErrNonFiniteInput
+	}
+	if opts.MaxLag < 0 {
+		return 0, 0, fmt.Errorf("%w: maximum lag must be non-negative, got %d", ErrInvalidOrder, opts.MaxLag)
+	}
+	maxLag = opts.MaxLag
+	if maxLag == 0 {
+		maxLag = int(10 * math.Log10(float64(n)/float64(numSeries)))
+	}
+	if maxLag > n-1 {
+		maxLag = n - 1
+	}
+	level = opts.Level
+	if level == 0 {
+		level = defaultConfidenceLevel
+	}
+	if err := validateLevels([]float64{level}); err != nil {
+		return 0, 0, err
+	}
+	return maxLag, level, nil
+}
+
+func newCorrelogram(firstLag int, values []float64, level float64) *Correlogram {
+	lags := make([]int, len(values))
+	for i := range lags {
+		lags[i] = firstLag + i
+	}
+	return &Correlogram{
+		Lags:   lags,
+		Values: values,
+		Bounds: make([]float64, len(values)),
+		Level:  level,
+	}
+}
+
+// autocovariances returns the biased sample autocovariances of data at
+// lags 0 to maxLag, about the mean if demean is set and about zero
+// otherwise. Pairs with a missing value (NaN) are skipped.
+func autocovariances(data []float64, maxLag int, demean bool) []float64 {
+	x := centred(data, demean)
+	n := float64(len(x))
+	r := make([]float64, maxLag+1)
+	for j := range r {
+		for i := 0; i < len(x)-j; i++ {
+			if product := x[i] * x[i+j]; !math.IsNaN(product) {
+				r[j] += product
+			}
+		}
+		r[j] /= n
+	}
+	return r
+}
+
+// autocorrelations returns the sample autocorrelations of data at lags 0
+// to maxLag.
+func autocorrelations(data []float64, maxLag int, demean bool) ([]float64, error) {
+	r := autocovariances(data, maxLag, demean)
+	if r[0] == 0 {
+		return nil, fmt.Errorf("%w: autocorrelations of a constant series", ErrSingularSystem)
+	}
+	acf := make([]float64, len(r))
+	for k := range r {
+		acf[k] = r[k] / r[0]
+	}
+	return acf, nil
+}
+
+// durbinLevinson returns the partial autocorrelations at lags 1 to
+// len(acf)-1 implied by the autocorrelations acf.
+func durbinLevinson(acf []float64) []float64 {
+	maxLag := len(acf) - 1
+	pacf := make([]float64, maxLag)
+	phi := make([]float64, 0, maxLag)
+	for k := 1; k <= maxLag; k++ {
+		numerator, denominator := acf[k], 1.0
+		for j, coefficient := range phi {
+			numerator -= coefficient * acf[k-1-j]
+			denominator -= coefficient * acf[j+1]
+		}
+		reflection := numerator / denominator
+		next := make([]float64, k)
+		for j := range phi {
+			next[j] = phi[j] - reflection*phi[k-2-j]
+		}
+		next[k-1] = reflection
+		phi = next
+		pacf[k-1] = reflection
+	}
+	return pacf
+}
+
+// centred returns a copy of data less the mean of its observed values if
+// demean is set.
+func centred(data []float64, demean bool) []float64 {
+	x := append([]float64(nil), data...)
+	if demean {
+		mean, observed := 0.0, 0
+		for _, v := range x {
+			if !math.IsNaN(v) {
+				mean += v
+				observed++
+			}
+		}
+		mean /= float64(observed)
+		for i := range x {
+			x[i] -= mean
+		}
+	}
+	return x
+}
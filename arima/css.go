@@ -0,0 +1,59 @@
+package arima
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/DoOR-Team/timeseries_forecasting/arima/matrix"
+)
+
+// estimateCSS sets the ARMA coefficients of params to the values that
+// minimize the conditional sum of squared one-step residuals on the
+// stationary series. The recursion is conditioned on the first
+// max(p, q) observations with zero initial errors.
+func estimateCSS(data []float64, params *Config) error {
+	numParams := params.getNumParamsP() + params.getNumParamsQ()
+	if numParams == 0 {
+		return nil
+	}
+	startIdx := int(math.Max(float64(params.getDegreeP()), float64(params.getDegreeQ())))
+	if len(data)-startIdx <= numParams {
+		return fmt.Errorf("%w: CSS needs more than %d points after %d conditioning lags, have %d",
+			ErrInsufficientData, numParams, startIdx, len(data))
+	}
+	objective := cssObjective(data, *params)
+	x, value := minimizeBFGS(objective, make([]float64, numParams))
+	if math.IsInf(value, 0) || math.IsNaN(value) {
+		return fmt.Errorf("%w: conditional sum of squares could not be evaluated", ErrSingularSystem)
+	}
+	params.setParamsFromVector(matrix.NewInsightVectorWithData(x, false))
+	return nil
+}
+
+// cssObjective returns half the log of the conditional mean squared
+// residual as a function of the ARMA parameter vector, or +Inf outside the
+// stationary and invertible region. It overwrites the coefficients of
+// params.
+func cssObjective(data []float64, params Config) func([]float64) float64 {
+	return func(x []float64) float64 {
+		if len(x) > 0 {
+			params.setParamsFromVector(matrix.NewInsightVectorWithData(x, false))
+		}
+		if !isAdmissible(params) {
+			return math.Inf(1)
+		}
+		squareSum := 0.0
+		nobs := 0
+		for _, e := range computeResiduals(params, data) {
+			if math.IsNaN(e) {
+				continue
+			}
+			squareSum += e * e
+			nobs++
+		}
+		if nobs == 0 || math.IsInf(squareSum, 0) || math.IsNaN(squareSum) {
+			return math.Inf(1)
+		}
+		return 0.5 * math.Log(squareSum/float64(nobs))
+	}
+}
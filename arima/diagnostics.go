@@ -0,0 +1,89 @@
+package arima
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/DoOR-Team/timeseries_forecasting/arima/tests"
+)
+
+const defaultARCHLags = 12
+
+// DiagnosticsOptions controls Model.Diagnostics. The zero value selects the
+// defaults.
+type DiagnosticsOptions struct {
+	// Lags for the portmanteau tests. Defaults to 10, or 2m for seasonal
+	// models, capped at n/5 but at least the number of ARMA coefficients
+	// plus 3.
+	Lags int
+	// ARCHLags for the ARCH-LM test. Defaults to 12, capped at n/5.
+	ARCHLags int
+}
+
+// Diagnostics holds tests of the residuals of a fitted model. Small
+// p-values point to structure the model has not captured: residual
+// autocorrelation (LjungBox, BoxPierce), non-normal errors (JarqueBera,
+// which also affects the prediction intervals) or volatility clustering
+// (ARCHLM).
+type Diagnostics struct {
+	LjungBox   *tests.Result
+	BoxPierce  *tests.Result
+	JarqueBera *tests.Result
+	ARCHLM     *tests.Result
+}
+
+// Diagnostics tests the one-step residuals of the fitted model. The
+// degrees of freedom of the portmanteau tests account for the estimated
+// ARMA coefficients.
+func (m *Model) Diagnostics(opts DiagnosticsOptions) (*Diagnostics, error) {
+	residuals := make([]float64, 0, len(m.residuals))
+	for _, e := range m.residuals {
+		if !math.IsNaN(e) {
+			residuals = append(residuals, e)
+		}
+	}
+	n := len(residuals)
+	fitdf := m.Params.getNumParamsP() + m.Params.getNumParamsQ()
+	if opts.Lags < 0 || opts.ARCHLags < 0 {
+		return nil, fmt.Errorf("%w: diagnostic lags must be non-negative", ErrInvalidOrder)
+	}
+	lags := opts.Lags
+	if lags == 0 {
+		lags = 10
+		if m.Params.m > 1 {
+			lags = 2 * m.Params.m
+		}
+		if lags > n/5 {
+			lags = n / 5
+		}
+		if lags < fitdf+3 {
+			lags = fitdf + 3
+		}
+	}
+	archLags := opts.ARCHLags
+	if archLags == 0 {
+		archLags = defaultARCHLags
+		if archLags > n/5 {
+			archLags = n / 5
+		}
+		if archLags < 1 {
+			archLags = 1
+		}
+	}
+
+	var diagnostics Diagnostics
+	var err error
+	if diagnostics.LjungBox, err = tests.LjungBox(residuals, lags, fitdf); err != nil {
+		return nil, err
+	}
+	if diagnostics.BoxPierce, err = tests.BoxPierce(residuals, lags, fitdf); err != nil {
+		return nil, err
+	}
+	if diagnostics.JarqueBera, err = tests.JarqueBera(residuals); err != nil {
+		return nil, err
+	}
+	if diagnostics.ARCHLM, err = tests.ARCHLM(residuals, archLags); err != nil {
+		return nil, err
+	}
+	return &diagnostics, nil
+}
@@ -0,0 +1,84 @@
+package arima
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/DoOR-Team/timeseries_forecasting/boxcox"
+	"github.com/DoOR-Team/timeseries_forecasting/distribution"
+)
+
+// ForecastDistribution returns the normal-theory distribution of the h
+// points past the end of the fitted data, with quantiles stored at
+// probabilities. Its quantiles match the intervals of ForecastInterval.
+// With a Box-Cox transform the distribution is normal on the transformed
+// scale: quantiles and exceedance probabilities stay exact, the mean is
+// bias-adjusted and the variance is unknown (NaN). Models with exogenous
+// regressors are not supported.
+func (m *Model) ForecastDistribution(h int, probabilities []float64) (*distribution.Forecast, error) {
+	if err := m.checkDistributionHorizon(h); err != nil {
+		return nil, err
+	}
+	forecastResult, err := m.forecast(h)
+	if err != nil {
+		return nil, err
+	}
+	scale := forecastResult.intervalScale()
+	sds := getCumulativeSumOfCoeff(m.Params.psiWeights(h))
+	mean := append([]float64(nil), forecastResult.Forecast...)
+	if m.regression != nil {
+		for i, offset := range m.regression.effect(nil, m.trainDataSize, h) {
+			mean[i] += offset
+		}
+	}
+	variance := make([]float64, h)
+	for i := range variance {
+		variance[i] = math.Pow(scale*sds[i], 2)
+	}
+	forecastDistribution, err := distribution.Normal(mean, variance, probabilities)
+	if err != nil {
+		return nil, err
+	}
+	if m.boxCox == nil {
+		return forecastDistribution, nil
+	}
+
+	lambda := m.boxCox.Lambda
+	mapped := forecastDistribution.Map(func(w float64) float64 {
+		return boxcox.Invert([]float64{w}, lambda)[0]
+	}, func(y float64) float64 {
+		transformed, err := boxcox.Apply([]float64{y}, lambda)
+		if err != nil {
+			// below the support of the back-transformed distribution
+			return math.Inf(-1)
+		}
+		return transformed[0]
+	})
+	mapped.Mean = boxcox.InvertBiasAdjusted(mean, variance, lambda)
+	return mapped, nil
+}
+
+// BootstrapDistribution returns the empirical distribution of the h points
+// past the end of the fitted data over future paths simulated as in
+// ForecastBootstrap, with quantiles stored at probabilities. The paths are
+// kept in the result. Models with exogenous regressors are not supported.
+func (m *Model) BootstrapDistribution(h int, probabilities []float64, opts distribution.BootstrapOptions) (*distribution.Forecast, error) {
+	if err := m.checkDistributionHorizon(h); err != nil {
+		return nil, err
+	}
+	paths, err := m.bootstrapPaths(h, opts)
+	if err != nil {
+		return nil, err
+	}
+	return distribution.FromSamples(paths, probabilities)
+}
+
+func (m *Model) checkDistributionHorizon(h int) error {
+	if m.regression.hasExogenous() {
+		return fmt.Errorf("%w: forecast distributions of models with regressors are not supported", ErrInvalidRegressors)
+	}
+	if h <= 0 {
+		return fmt.Errorf("%w: forecast size must be positive, got %d", ErrInvalidOrder, h)
+	}
+	return nil
+}
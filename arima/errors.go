@@ -0,0 +1,29 @@
+package arima
+
+import (
+	"errors"
+
+	"github.com/DoOR-Team/timeseries_forecasting/arima/utils"
+)
+
+// Sentinel errors returned by the arima package. Errors are wrapped with
+// additional context, so compare them with errors.Is.
+var (
+	// ErrInsufficientData is returned when the series is too short for the
+	// requested model, e.g. shorter than its differencing initial conditions.
+	ErrInsufficientData = utils.ErrInsufficientData
+	// ErrInvalidOrder is returned for negative or inconsistent model orders.
+	ErrInvalidOrder = errors.New("invalid model order")
+	// ErrSingularSystem is returned when a least squares or Yule-Walker
+	// system cannot be solved.
+	ErrSingularSystem = errors.New("singular linear system")
+	// ErrNonFiniteInput is returned when the input contains Inf, or NaN
+	// where missing values are not supported.
+	ErrNonFiniteInput = errors.New("non-finite input")
+	// ErrInvalidRegressors is returned when exogenous regressors are missing
+	// or do not match the series or the fitted model.
+	ErrInvalidRegressors = errors.New("invalid exogenous regressors")
+	// ErrInadmissible is returned when estimated coefficients are not
+	// stationary or not invertible and the fit was asked to reject them.
+	ErrInadmissible = errors.New("non-stationary or non-invertible coefficients")
+)
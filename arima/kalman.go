@@ -0,0 +1,268 @@
+package arima
+
+import "math"
+
+const (
+	maxDoublingIterations = 64
+	doublingTolerance     = 1e-12
+)
+
+// armaStateSpace is the Harvey state-space form of a zero-mean ARMA process
+//
+//	y_t     = Z a_t
+//	a_{t+1} = T a_t + R e_t,  Var(e_t) = sigma^2
+//
+// with Z = (1, 0, ..., 0), T holding the AR coefficients in its first
+// column and ones on its superdiagonal, and R = (1, theta_1, ...,
+// theta_{r-1}). The filter runs with sigma^2 = 1, which is concentrated out
+// of the likelihood.
+type armaStateSpace struct {
+	r     int
+	phi   []float64 // AR coefficients padded to length r
+	theta []float64 // (1, MA coefficients) padded to length r
+}
+
+func newARMAStateSpace(ar, ma []float64) *armaStateSpace {
+	r := len(ar)
+	if len(ma)+1 > r {
+		r = len(ma) + 1
+	}
+	ss := &armaStateSpace{
+		r:     r,
+		phi:   make([]float64, r),
+		theta: make([]float64, r),
+	}
+	copy(ss.phi, ar)
+	ss.theta[0] = 1
+	copy(ss.theta[1:], ma)
+	return ss
+}
+
+// transitionTimes returns T * A for a square matrix A.
+func (ss *armaStateSpace) transitionTimes(a [][]float64) [][]float64 {
+	r := ss.r
+	out := newSquare(r)
+	for i := 0; i < r; i++ {
+		for j := 0; j < r; j++ {
+			v := ss.phi[i] * a[0][j]
+			if i+1 < r {
+				v += a[i+1][j]
+			}
+			out[i][j] = v
+		}
+	}
+	return out
+}
+
+// propagate returns T P T' + R R'.
+func (ss *armaStateSpace) propagate(p [][]float64) [][]float64 {
+	r := ss.r
+	tp := ss.transitionTimes(p)
+	out := newSquare(r)
+	for i := 0; i < r; i++ {
+		for j := 0; j < r; j++ {
+			v := tp[i][0] * ss.phi[j]
+			if j+1 < r {
+				v += tp[i][j+1]
+			}
+			out[i][j] = v + ss.theta[i]*ss.theta[j]
+		}
+	}
+	return out
+}
+
+// initialCovariance returns the stationary state covariance solving
+// P = T P T' + R R' with the doubling algorithm. The AR part must be
+// stationary for it to converge; ok is false otherwise.
+func (ss *armaStateSpace) initialCovariance() (p [][]float64, ok bool) {
+	r := ss.r
+	// A_0 = T, P_0 = R R'
+	a := newSquare(r)
+	p = newSquare(r)
+	for i := 0; i < r; i++ {
+		a[i][0] = ss.phi[i]
+		if i+1 < r {
+			a[i][i+1] = 1
+		}
+		for j := 0; j < r; j++ {
+			p[i][j] = ss.theta[i] * ss.theta[j]
+		}
+	}
+	for iter := 0; iter < maxDoublingIterations; iter++ {
+		// P_{k+1} = P_k + A_k P_k A_k', A_{k+1} = A_k A_k
+		apa := multiplySquare(multiplySquare(a, p), transposeSquare(a))
+		change := 0.0
+		scale := 0.0
+		for i := 0; i < r; i++ {
+			for j := 0; j < r; j++ {
+				p[i][j] += apa[i][j]
+				change += math.Abs(apa[i][j])
+				scale += math.Abs(p[i][j])
+			}
+		}
+		if math.IsNaN(change) || math.IsInf(change, 0) {
+			return nil, false
+		}
+		if change <= doublingTolerance*scale {
+			return p, true
+		}
+		a = multiplySquare(a, a)
+	}
+	return nil, false
+}
+
+// kalmanResult holds the output of the Kalman filter run with sigma^2 = 1.
+type kalmanResult struct {
+	innovations []float64 // one-step prediction errors v_t
+	variances   []float64 // their variances F_t, in units of sigma^2
+	sumSquares  float64   // sum of v_t^2 / F_t
+	sumLogF     float64   // sum of log F_t
+	nobs        int
+	final       *kalmanState // the filter after the last observation
+}
+
+// kalmanState is the one-step prediction of the state and its covariance,
+// in units of sigma^2.
+type kalmanState struct {
+	ss *armaStateSpace
+	a  []float64
+	p  [][]float64
+}
+
+// newKalmanState returns the filter before the first observation, with the
+// stationary state covariance; ok is false if there is none.
+func (ss *armaStateSpace) newKalmanState() (*kalmanState, bool) {
+	p, ok := ss.initialCovariance()
+	if !ok {
+		return nil, false
+	}
+	return &kalmanState{ss: ss, a: make([]float64, ss.r), p: p}, true
+}
+
+// clone returns a copy of k that can be stepped without changing k.
+func (k *kalmanState) clone() *kalmanState {
+	p := newSquare(k.ss.r)
+	for i := range p {
+		copy(p[i], k.p[i])
+	}
+	return &kalmanState{ss: k.ss, a: append([]float64(nil), k.a...), p: p}
+}
+
+// step updates the state with the observation y and predicts the next one.
+// It returns the innovation, NaN if y is missing, and its variance; ok is
+// false if the variance is not positive.
+func (k *kalmanState) step(y float64) (v, f float64, ok bool) {
+	ss := k.ss
+	r := ss.r
+	state, p := k.a, k.p
+	f = p[0][0]
+	if f <= 0 {
+		return 0, 0, false
+	}
+	v = math.NaN()
+	// a missing observation carries no information: predict without
+	// updating
+	if !math.IsNaN(y) {
+		v = y - state[0]
+		gain := make([]float64, r)
+		for i := 0; i < r; i++ {
+			gain[i] = p[i][0] / f
+			state[i] += gain[i] * v
+		}
+		for i := 0; i < r; i++ {
+			for j := 0; j < r; j++ {
+				p[i][j] -= gain[i] * f * gain[j]
+			}
+		}
+	}
+
+	// predict
+	first := state[0]
+	for i := 0; i < r-1; i++ {
+		state[i] = ss.phi[i]*first + state[i+1]
+	}
+	state[r-1] = ss.phi[r-1] * first
+	k.p = ss.propagate(p)
+	return v, f, true
+}
+
+// filter runs the Kalman filter over the zero-mean series data. Missing
+// values (NaN) are skipped; their innovations are NaN.
+func (ss *armaStateSpace) filter(data []float64) (*kalmanResult, bool) {
+	state, ok := ss.newKalmanState()
+	if !ok {
+		return nil, false
+	}
+	result := &kalmanResult{
+		innovations: make([]float64, len(data)),
+		variances:   make([]float64, len(data)),
+		final:       state,
+	}
+	for t, y := range data {
+		v, f, ok := state.step(y)
+		if !ok {
+			return nil, false
+		}
+		result.innovations[t] = v
+		result.variances[t] = f
+		if !math.IsNaN(v) {
+			result.sumSquares += v * v / f
+			result.sumLogF += math.Log(f)
+			result.nobs++
+		}
+	}
+	return result, true
+}
+
+// logLikelihood returns the exact Gaussian log-likelihood with sigma^2
+// concentrated out, together with its maximum likelihood estimate.
+func (k *kalmanResult) logLikelihood() (logLik, sigma2 float64) {
+	n := float64(k.nobs)
+	sigma2 = k.sumSquares / n
+	logLik = -0.5 * (n*math.Log(2*math.Pi*sigma2) + k.sumLogF + n)
+	return
+}
+
+// standardizedResiduals returns v_t / sqrt(F_t), which have variance sigma^2.
+func (k *kalmanResult) standardizedResiduals() []float64 {
+	residuals := make([]float64, len(k.innovations))
+	for t, v := range k.innovations {
+		residuals[t] = v / math.Sqrt(k.variances[t])
+	}
+	return residuals
+}
+
+func newSquare(r int) [][]float64 {
+	m := make([][]float64, r)
+	for i := range m {
+		m[i] = make([]float64, r)
+	}
+	return m
+}
+
+func multiplySquare(a, b [][]float64) [][]float64 {
+	r := len(a)
+	out := newSquare(r)
+	for i := 0; i < r; i++ {
+		for k := 0; k < r; k++ {
+			if a[i][k] == 0 {
+				continue
+			}
+			for j := 0; j < r; j++ {
+				out[i][j] += a[i][k] * b[k][j]
+			}
+		}
+	}
+	return out
+}
+
+func transposeSquare(a [][]float64) [][]float64 {
+	r := len(a)
+	out := newSquare(r)
+	for i := 0; i < r; i++ {
+		for j := 0; j < r; j++ {
+			out[j][i] = a[i][j]
+		}
+	}
+	return out
+}
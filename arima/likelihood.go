@@ -0,0 +1,102 @@
+package arima
+
+import "math"
+
+// setLikelihood computes and stores the conditional residuals of a
+// Hannan-Rissanen or CSS fit on the stationary series. NaN residuals are the conditioning and missing observations.
+// The likelihood is the exact one at the estimated coefficients, over every
+// observed difference, so that the criteria of models of different orders
+// cover the same sample, and sigma^2 is the estimate that maximizes it.
+// For inadmissible coefficients, which have no exact likelihood, both come
+// from the conditional sum of squared residuals.
+func (m *Model) setLikelihood(params Config) {
+	residuals, tail := conditionalResiduals(params, m.stationary)
+	m.residuals = residuals
+	m.innovations = residuals
+	m.tail = tail
+	m.filter = nil
+	if result, ok := params.exactFilter(m.stationary, nil); ok && result.nobs > 0 {
+		m.sumSquares, m.sumLogF, m.nobs = result.sumSquares, result.sumLogF, result.nobs
+		m.filter = result.final
+		m.concentrate()
+		return
+	}
+	m.sumSquares, m.sumLogF, m.nobs = 0, 0, 0
+	for _, e := range residuals {
+		if math.IsNaN(e) {
+			continue
+		}
+		m.sumSquares += e * e
+		m.nobs++
+	}
+	m.concentrate()
+}
+
+// concentrate sets sigma^2 and the log-likelihood from the sums of the
+// squared scaled innovations and of the log of their variances.
+func (m *Model) concentrate() {
+	if m.nobs == 0 {
+		m.sigma2 = math.NaN()
+		m.logLik = math.NaN()
+		return
+	}
+	n := float64(m.nobs)
+	m.sigma2 = m.sumSquares / n
+	m.logLik = -0.5 * (n*math.Log(2*math.Pi*m.sigma2) + m.sumLogF + n)
+}
+
+// LogLikelihood returns the Gaussian log-likelihood of the fitted
+// coefficients on the differenced series with sigma^2 concentrated out.
+// It is the exact likelihood whatever the estimator, unless the
+// coefficients are not admissible.
+func (m *Model) LogLikelihood() float64 {
+	return m.logLik
+}
+
+// Sigma2 returns the estimate of the innovation variance that maximizes
+// LogLikelihood at the fitted coefficients. For MethodML and MethodCSSML
+// it is the maximum likelihood estimate; for MethodHannanRissanen and
+// MethodCSS it comes from the same exact filter at their estimates, not
+// from the conditional residuals.
+func (m *Model) Sigma2() float64 {
+	return m.sigma2
+}
+
+// NumObs returns the number of observations the likelihood is based on:
+// the length of the differenced series less the missing values, whatever
+// the estimator.
+func (m *Model) NumObs() int {
+	return m.nobs
+}
+
+// NumParams counts the estimated ARMA coefficients, p+q+P+Q for a seasonal
+// model whose cross lags follow from the factors, the regression and
+// constant coefficients and sigma^2.
+func (m *Model) NumParams() int {
+	numParams := m.Params.getNumParamsP() + m.Params.getNumParamsQ() + 1
+	if m.regression != nil {
+		numParams += len(m.regression.beta)
+	}
+	return numParams
+}
+
+// AIC returns Akaike's information criterion, -2*loglik + 2*k.
+func (m *Model) AIC() float64 {
+	return -2*m.logLik + 2*float64(m.NumParams())
+}
+
+// AICc returns the small-sample corrected AIC. It is +Inf when there are
+// not more observations than parameters plus one.
+func (m *Model) AICc() float64 {
+	k := float64(m.NumParams())
+	n := float64(m.nobs)
+	if n-k-1 <= 0 {
+		return math.Inf(1)
+	}
+	return m.AIC() + 2*k*(k+1)/(n-k-1)
+}
+
+// BIC returns the Bayesian information criterion, -2*loglik + k*log(n).
+func (m *Model) BIC() float64 {
+	return -2*m.logLik + float64(m.NumParams())*math.Log(float64(m.nobs))
+}
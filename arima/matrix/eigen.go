@@ -0,0 +1,224 @@
+package matrix
+
+import "math"
+
+const maxIterationsHQR = 30
+
+// HessenbergEigenvalues returns the eigenvalues of an upper Hessenberg
+// matrix, such as a companion matrix, by the shifted QR algorithm. The
+// matrix is balanced first and is not modified. ok is false if the
+// iteration did not converge.
+func (m *InsightsMatrix) HessenbergEigenvalues() (eigenvalues []complex128, ok bool) {
+	if !m._valid || m._m != m._n {
+		panic("[InsightsMatrix][hessenbergEigenvalues] matrix must be square")
+	}
+	a := copy2DArray(m._data)
+	balance(a)
+	return hqr(a)
+}
+
+// balance applies a diagonal similarity transform that makes the row and
+// column norms of a comparable, which reduces the rounding error of the
+// eigenvalues. It preserves the Hessenberg form.
+func balance(a [][]float64) {
+	const radix = 2.
+	n := len(a)
+	done := false
+	for !done {
+		done = true
+		for i := 0; i < n; i++ {
+			r, c := 0., 0.
+			for j := 0; j < n; j++ {
+				if j != i {
+					c += math.Abs(a[j][i])
+					r += math.Abs(a[i][j])
+				}
+			}
+			if c == 0 || r == 0 {
+				continue
+			}
+			g := r / radix
+			f := 1.
+			s := c + r
+			for c < g {
+				f *= radix
+				c *= radix * radix
+			}
+			g = r * radix
+			for c > g {
+				f /= radix
+				c /= radix * radix
+			}
+			if (c+r)/f < 0.95*s {
+				done = false
+				g = 1 / f
+				for j := 0; j < n; j++ {
+					a[i][j] *= g
+				}
+				for j := 0; j < n; j++ {
+					a[j][i] *= f
+				}
+			}
+		}
+	}
+}
+
+// hqr computes the eigenvalues of the upper Hessenberg matrix a in place,
+// following the EISPACK routine of the same name.
+func hqr(a [][]float64) ([]complex128, bool) {
+	n := len(a)
+	eigenvalues := make([]complex128, n)
+	eps := math.Nextafter(1, 2) - 1
+	anorm := 0.
+	for i := 0; i < n; i++ {
+		for j := int(math.Max(float64(i-1), 0)); j < n; j++ {
+			anorm += math.Abs(a[i][j])
+		}
+	}
+	var p, q, r, s, t, w, x, y, z float64
+	nn := n - 1
+	for nn >= 0 {
+		its := 0
+		var l int
+		for {
+			for l = nn; l > 0; l-- {
+				s = math.Abs(a[l-1][l-1]) + math.Abs(a[l][l])
+				if s == 0 {
+					s = anorm
+				}
+				if math.Abs(a[l][l-1]) <= eps*s {
+					a[l][l-1] = 0
+					break
+				}
+			}
+			x = a[nn][nn]
+			if l == nn {
+				// one root found
+				eigenvalues[nn] = complex(x+t, 0)
+				nn--
+			} else {
+				y = a[nn-1][nn-1]
+				w = a[nn][nn-1] * a[nn-1][nn]
+				if l == nn-1 {
+					// two roots found
+					p = 0.5 * (y - x)
+					q = p*p + w
+					z = math.Sqrt(math.Abs(q))
+					x += t
+					if q >= 0 {
+						z = p + math.Copysign(z, p)
+						eigenvalues[nn-1] = complex(x+z, 0)
+						eigenvalues[nn] = eigenvalues[nn-1]
+						if z != 0 {
+							eigenvalues[nn] = complex(x-w/z, 0)
+						}
+					} else {
+						eigenvalues[nn] = complex(x+p, -z)
+						eigenvalues[nn-1] = complex(x+p, z)
+					}
+					nn -= 2
+				} else {
+					if its == maxIterationsHQR {
+						return nil, false
+					}
+					if its == 10 || its == 20 {
+						// exceptional shift
+						t += x
+						for i := 0; i <= nn; i++ {
+							a[i][i] -= x
+						}
+						s = math.Abs(a[nn][nn-1]) + math.Abs(a[nn-1][nn-2])
+						x = 0.75 * s
+						y = x
+						w = -0.4375 * s * s
+					}
+					its++
+					var mm int
+					for mm = nn - 2; mm >= l; mm-- {
+						z = a[mm][mm]
+						r = x - z
+						s = y - z
+						p = (r*s-w)/a[mm+1][mm] + a[mm][mm+1]
+						q = a[mm+1][mm+1] - z - r - s
+						r = a[mm+2][mm+1]
+						s = math.Abs(p) + math.Abs(q) + math.Abs(r)
+						p /= s
+						q /= s
+						r /= s
+						if mm == l {
+							break
+						}
+						u := math.Abs(a[mm][mm-1]) * (math.Abs(q) + math.Abs(r))
+						v := math.Abs(p) * (math.Abs(a[mm-1][mm-1]) + math.Abs(z) + math.Abs(a[mm+1][mm+1]))
+						if u <= eps*v {
+							break
+						}
+					}
+					for i := mm; i < nn-1; i++ {
+						a[i+2][i] = 0
+						if i != mm {
+							a[i+2][i-1] = 0
+						}
+					}
+					// double QR step on rows l..nn and columns mm..nn
+					for k := mm; k < nn; k++ {
+						if k != mm {
+							p = a[k][k-1]
+							q = a[k+1][k-1]
+							r = 0
+							if k+1 != nn {
+								r = a[k+2][k-1]
+							}
+							if x = math.Abs(p) + math.Abs(q) + math.Abs(r); x != 0 {
+								p /= x
+								q /= x
+								r /= x
+							}
+						}
+						if s = math.Copysign(math.Sqrt(p*p+q*q+r*r), p); s != 0 {
+							if k == mm {
+								if l != mm {
+									a[k][k-1] = -a[k][k-1]
+								}
+							} else {
+								a[k][k-1] = -s * x
+							}
+							p += s
+							x = p / s
+							y = q / s
+							z = r / s
+							q /= p
+							r /= p
+							for j := k; j <= nn; j++ {
+								p = a[k][j] + q*a[k+1][j]
+								if k+1 != nn {
+									p += r * a[k+2][j]
+									a[k+2][j] -= p * z
+								}
+								a[k+1][j] -= p * y
+								a[k][j] -= p * x
+							}
+							mmin := nn
+							if k+3 < nn {
+								mmin = k + 3
+							}
+							for i := l; i <= mmin; i++ {
+								p = x*a[i][k] + y*a[i][k+1]
+								if k+1 != nn {
+									p += z * a[i][k+2]
+									a[i][k+2] -= p * r
+								}
+								a[i][k+1] -= p * q
+								a[i][k] -= p
+							}
+						}
+					}
+				}
+			}
+			if l+1 >= nn {
+				break
+			}
+		}
+	}
+	return eigenvalues, true
+}
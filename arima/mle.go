@@ -0,0 +1,77 @@
+package arima
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/DoOR-Team/timeseries_forecasting/arima/matrix"
+)
+
+// estimateML sets the ARMA coefficients of params to the exact Gaussian
+// maximum likelihood estimates on the stationary series, starting
+// the optimizer at start (zeros if nil). It returns the Kalman filter
+// output at the optimum.
+func estimateML(data []float64, params *Config, start []float64) (*kalmanResult, error) {
+	numParams := params.getNumParamsP() + params.getNumParamsQ()
+	missing, _ := countMissing(data)
+	if len(data)-missing <= numParams {
+		return nil, fmt.Errorf("%w: exact likelihood needs more than %d observed points, have %d",
+			ErrInsufficientData, numParams, len(data)-missing)
+	}
+	objective := exactObjective(data, *params)
+
+	x0 := make([]float64, numParams)
+	copy(x0, start)
+	if math.IsInf(objective(x0), 1) {
+		// the warm start is not admissible; fall back to white noise
+		x0 = make([]float64, numParams)
+	}
+	x, value := minimizeBFGS(objective, x0)
+	if math.IsInf(value, 0) || math.IsNaN(value) {
+		return nil, fmt.Errorf("%w: exact likelihood could not be evaluated", ErrSingularSystem)
+	}
+	result, ok := params.exactFilter(data, x)
+	if !ok {
+		return nil, fmt.Errorf("%w: exact likelihood could not be evaluated", ErrSingularSystem)
+	}
+	return result, nil
+}
+
+// exactObjective returns the negative exact log-likelihood per observation
+// as a function of the ARMA parameter vector. It overwrites the
+// coefficients of params.
+func exactObjective(data []float64, params Config) func([]float64) float64 {
+	n := float64(len(data))
+	return func(x []float64) float64 {
+		result, ok := params.exactFilter(data, x)
+		if !ok {
+			return math.Inf(1)
+		}
+		logLik, _ := result.logLikelihood()
+		return -logLik / n
+	}
+}
+
+// exactFilter sets the ARMA coefficients of c from x and runs the Kalman
+// filter over data. ok is false for non-stationary or non-invertible
+// coefficients.
+func (c Config) exactFilter(data []float64, x []float64) (*kalmanResult, bool) {
+	if len(x) > 0 {
+		c.setParamsFromVector(matrix.NewInsightVectorWithData(x, false))
+	}
+	ar, ma := c.armaCoefficients()
+	if !isStationaryAR(ar) || !isInvertibleMA(ma) {
+		return nil, false
+	}
+	return newARMAStateSpace(ar, ma).filter(data)
+}
+
+// setExactLikelihood stores the likelihood and standardized residuals of
+// an exact Kalman filter fit.
+func (m *Model) setExactLikelihood(result *kalmanResult) {
+	m.residuals = result.standardizedResiduals()
+	m.innovations = result.innovations
+	m.sumSquares, m.sumLogF, m.nobs = result.sumSquares, result.sumLogF, result.nobs
+	m.filter = result.final
+	m.concentrate()
+}
@@ -1,33 +1,220 @@
 package arima
 
+import (
+	"fmt"
+	"math"
+
+	"github.com/DoOR-Team/timeseries_forecasting/boxcox"
+)
+
 type Model struct {
 	Params        Config
 	data          []float64
 	trainDataSize int
 	RMSE          float64
 	solver        *Solver
+
+	// stationary series and the estimator used on it
+	stationary []float64
+	method     Method
+	covariance [][]float64
+	correction Correction
+
+	// exogenous regressors and constant terms, nil if there are none
+	regression *regression
+	// resolved Box-Cox transform of the data, nil if there is none
+	boxCox *boxcox.Transform
+	// number of missing (NaN) observations in the fitted data
+	missing int
+
+	// one-step residuals of the stationary series; for MethodML and
+	// MethodCSSML the residuals are standardized and innovations holds the
+	// raw prediction errors
+	residuals   []float64
+	innovations []float64
+	// the likelihood: the sums of the squared scaled innovations and of
+	// the log of their variances over nobs observations, and the sigma^2
+	// and log-likelihood they give
+	sumSquares float64
+	sumLogF    float64
+	nobs       int
+	sigma2     float64
+	logLik     float64
+	// the exact filter, nil if the coefficients have no exact likelihood,
+	// and the conditional recursion after the last observation, which
+	// Append continues
+	filter *kalmanState
+	tail   armaTail
+
+	// what Append needs to refit: the observations on the original scale,
+	// the orders and options of the fit, the points appended since it, the
+	// residual standard deviation it found and the number of refits so far
+	observed  []float64
+	spec      Config
+	options   FitOptions
+	sinceFit  int
+	fitSD     float64
+	numRefits int
+}
+
+// Forecast forecasts h points past the end of the fitted data with the
+// estimated coefficients, together with a 95% prediction interval.
+func (m *Model) Forecast(h int) (*Result, error) {
+	return m.ForecastInterval(h, []float64{defaultConfidenceLevel})
+}
+
+// ForecastInterval forecasts h points past the end of the fitted data and
+// computes a prediction interval for each confidence level in levels, e.g.
+// 0.8 and 0.95. The model is not re-estimated.
+func (m *Model) ForecastInterval(h int, levels []float64) (*Result, error) {
+	if m.regression.hasExogenous() {
+		return nil, fmt.Errorf("%w: model has regressors, use ForecastWithRegressors", ErrInvalidRegressors)
+	}
+	return m.forecastInterval(h, nil, levels)
+}
+
+// ForecastWithRegressors forecasts a regression with ARIMA errors given the
+// future values of its regressors, one slice per regressor in the order
+// used for fitting. The horizon is the length of the slices. Prediction
+// intervals at levels (95% if none is given) reflect the ARIMA error
+// process.
+func (m *Model) ForecastWithRegressors(xreg [][]float64, levels ...float64) (*Result, error) {
+	if !m.regression.hasExogenous() {
+		return nil, fmt.Errorf("%w: model was fitted without regressors", ErrInvalidRegressors)
+	}
+	if len(xreg) != len(m.regression.xreg) {
+		return nil, fmt.Errorf("%w: expected %d regressors, got %d",
+			ErrInvalidRegressors, len(m.regression.xreg), len(xreg))
+	}
+	if err := validateRegressors(xreg, len(xreg[0])); err != nil {
+		return nil, err
+	}
+	if len(levels) == 0 {
+		levels = []float64{defaultConfidenceLevel}
+	}
+	return m.forecastInterval(len(xreg[0]), xreg, levels)
+}
+
+func (m *Model) forecastInterval(h int, xreg [][]float64, levels []float64) (*Result, error) {
+	if h <= 0 {
+		return nil, fmt.Errorf("%w: forecast size must be positive, got %d", ErrInvalidOrder, h)
+	}
+	if err := validateLevels(levels); err != nil {
+		return nil, err
+	}
+	forecastResult, err := m.forecast(h)
+	if err != nil {
+		return nil, err
+	}
+	forecastResult.maxNormalizedVariance = setPredictionIntervals(m.Params, forecastResult, levels)
+	if m.regression != nil {
+		forecastResult.shift(m.regression.effect(xreg, m.trainDataSize, h))
+	}
+	if m.boxCox != nil {
+		forecastResult.backTransform(*m.boxCox)
+	}
+	return forecastResult, nil
 }
 
-// func (m *Model) ForecastResult(data []float64, forecastSize int) []float64 {
-// 	fittedModel := estimateARIMA(
-// 		m, data, len(data), len(data)+1)
-//
-// 	paramsXValidation := m.Config
-// 	rmseValidation := computeRMSEValidation(
-// 		m.Config, testSetPercentage, paramsXValidation)
-//
-// 	forecastResult := fittedModel.forecast(forecastSize)
-// 	// forecastResult.setSigma2AndPredicationInterval(m)
-// 	return forecastResult
-// }
-
-func (m *Model) forecast(forecastSize int) *Result {
-	forecastResult := forecastARIMA(m.Params, m.data, m.trainDataSize, m.trainDataSize+forecastSize)
+func (m *Model) forecast(forecastSize int) (*Result, error) {
+	forecastResult, err := forecastARIMA(m.Params, m.data, m.trainDataSize, m.trainDataSize+forecastSize)
+	if err != nil {
+		return nil, err
+	}
 	forecastResult.modelRMSE = m.RMSE
-	// forecastResult.setSigma2AndPredicationInterval(m)
-	return forecastResult
+	if !math.IsNaN(m.sigma2) {
+		forecastResult.residualSD = math.Sqrt(m.sigma2)
+	}
+	return forecastResult, nil
 }
 
 func (m *Model) GetParams() Config {
 	return m.Params
 }
+
+// NumMissing returns the number of missing (NaN) observations in the
+// fitted data.
+func (m *Model) NumMissing() int {
+	return m.missing
+}
+
+// BoxCox returns the Box-Cox transform the model was fitted with, with
+// its lambda resolved, and whether there is one.
+func (m *Model) BoxCox() (boxcox.Transform, bool) {
+	if m.boxCox == nil {
+		return boxcox.Transform{}, false
+	}
+	return *m.boxCox, true
+}
+
+// Residuals returns the one-step-ahead prediction errors of the fitted
+// model on the original scale, aligned with the fitted data. They are NaN
+// for the observations consumed by differencing and, except for
+// MethodML and MethodCSSML, by conditioning the ARMA recursion, as well as
+// at missing observations. With a Box-Cox transform they are the data less
+// FittedValues.
+func (m *Model) Residuals() []float64 {
+	if m.boxCox == nil {
+		return m.innovationsByTime()
+	}
+	residuals := m.FittedValues()
+	observed := boxcox.Invert(m.transformedData(), m.boxCox.Lambda)
+	for t := range residuals {
+		residuals[t] = observed[t] - residuals[t]
+	}
+	return residuals
+}
+
+// FittedValues returns the one-step-ahead predictions of the fitted data,
+// including any regression effect, and NaN at missing observations.
+// Without a Box-Cox transform they are the data less Residuals; with one
+// they are transformed back, as means if the transform is bias adjusted.
+func (m *Model) FittedValues() []float64 {
+	fitted := m.innovationsByTime()
+	observed := m.transformedData()
+	for t := range fitted {
+		fitted[t] = observed[t] - fitted[t]
+	}
+	if m.boxCox == nil {
+		return fitted
+	}
+	if !m.boxCox.BiasAdjust {
+		return boxcox.Invert(fitted, m.boxCox.Lambda)
+	}
+	variances := make([]float64, len(fitted))
+	for t := range variances {
+		variances[t] = m.sigma2
+	}
+	return boxcox.InvertBiasAdjusted(fitted, variances, m.boxCox.Lambda)
+}
+
+// innovationsByTime aligns the one-step prediction errors on the fitted
+// scale with the fitted data, with NaN where they are undefined.
+func (m *Model) innovationsByTime() []float64 {
+	offset := m.Params.d
+	if m.Params.m > 0 {
+		offset += m.Params.D * m.Params.m
+	}
+	innovations := make([]float64, m.trainDataSize)
+	for t := range innovations {
+		if t < offset || t-offset >= len(m.innovations) {
+			innovations[t] = math.NaN()
+			continue
+		}
+		innovations[t] = m.innovations[t-offset]
+	}
+	return innovations
+}
+
+// transformedData returns the fitted data, after any Box-Cox transform,
+// including any regression effect.
+func (m *Model) transformedData() []float64 {
+	// m.data holds the regression errors when there is a regression
+	observed := append([]float64(nil), m.data[:m.trainDataSize]...)
+	if m.regression != nil {
+		for t, effect := range m.regression.fittedEffect(m.trainDataSize) {
+			observed[t] += effect
+		}
+	}
+	return observed
+}
@@ -0,0 +1,165 @@
+package arima
+
+import "math"
+
+const (
+	maxOptimizerIterations = 100
+	optimizerRelTolerance  = 1e-8
+	gradientStep           = 1e-4
+	hessianStep            = 1e-3
+)
+
+// minimizeBFGS minimizes f starting at x0 with the BFGS quasi-Newton
+// method, using central-difference gradients and a backtracking line
+// search. f may return +Inf outside the admissible region. It returns the
+// best point found and its value.
+func minimizeBFGS(f func([]float64) float64, x0 []float64) ([]float64, float64) {
+	n := len(x0)
+	x := append([]float64(nil), x0...)
+	fx := f(x)
+	if n == 0 || math.IsInf(fx, 0) || math.IsNaN(fx) {
+		return x, fx
+	}
+	g := numericGradient(f, x, fx)
+
+	// inverse Hessian approximation
+	h := newSquare(n)
+	for i := 0; i < n; i++ {
+		h[i][i] = 1
+	}
+
+	for iter := 0; iter < maxOptimizerIterations; iter++ {
+		direction := make([]float64, n)
+		slope := 0.0
+		for i := 0; i < n; i++ {
+			for j := 0; j < n; j++ {
+				direction[i] -= h[i][j] * g[j]
+			}
+			slope += direction[i] * g[i]
+		}
+		if slope >= 0 {
+			// not a descent direction: restart from steepest descent
+			for i := 0; i < n; i++ {
+				for j := 0; j < n; j++ {
+					h[i][j] = 0
+				}
+				h[i][i] = 1
+				direction[i] = -g[i]
+			}
+			slope = -dot(g, g)
+			if slope == 0 {
+				break
+			}
+		}
+
+		step := 1.0
+		next := make([]float64, n)
+		var fNext float64
+		accepted := false
+		for k := 0; k < 40; k++ {
+			for i := 0; i < n; i++ {
+				next[i] = x[i] + step*direction[i]
+			}
+			fNext = f(next)
+			if !math.IsNaN(fNext) && fNext <= fx+1e-4*step*slope {
+				accepted = true
+				break
+			}
+			step *= 0.5
+		}
+		if !accepted {
+			break
+		}
+
+		gNext := numericGradient(f, next, fNext)
+		s := make([]float64, n)
+		y := make([]float64, n)
+		for i := 0; i < n; i++ {
+			s[i] = next[i] - x[i]
+			y[i] = gNext[i] - g[i]
+		}
+		converged := math.Abs(fx-fNext) <= optimizerRelTolerance*(math.Abs(fx)+optimizerRelTolerance)
+		x, fx, g = next, fNext, gNext
+		if converged {
+			break
+		}
+
+		sy := dot(s, y)
+		if sy <= 1e-12 {
+			continue
+		}
+		// BFGS update of the inverse Hessian
+		hy := make([]float64, n)
+		for i := 0; i < n; i++ {
+			for j := 0; j < n; j++ {
+				hy[i] += h[i][j] * y[j]
+			}
+		}
+		yhy := dot(y, hy)
+		for i := 0; i < n; i++ {
+			for j := 0; j < n; j++ {
+				h[i][j] += ((sy+yhy)*s[i]*s[j])/(sy*sy) - (hy[i]*s[j]+s[i]*hy[j])/sy
+			}
+		}
+	}
+	return x, fx
+}
+
+// numericGradient returns the central-difference gradient of f at x,
+// falling back to a one-sided difference next to an inadmissible region.
+func numericGradient(f func([]float64) float64, x []float64, fx float64) []float64 {
+	n := len(x)
+	g := make([]float64, n)
+	probe := append([]float64(nil), x...)
+	for i := 0; i < n; i++ {
+		probe[i] = x[i] + gradientStep
+		fPlus := f(probe)
+		probe[i] = x[i] - gradientStep
+		fMinus := f(probe)
+		probe[i] = x[i]
+		plusOK := !math.IsInf(fPlus, 0) && !math.IsNaN(fPlus)
+		minusOK := !math.IsInf(fMinus, 0) && !math.IsNaN(fMinus)
+		switch {
+		case plusOK && minusOK:
+			g[i] = (fPlus - fMinus) / (2 * gradientStep)
+		case plusOK:
+			g[i] = (fPlus - fx) / gradientStep
+		case minusOK:
+			g[i] = (fx - fMinus) / gradientStep
+		}
+	}
+	return g
+}
+
+// numericHessian returns the central-difference Hessian of f at x.
+func numericHessian(f func([]float64) float64, x []float64) [][]float64 {
+	n := len(x)
+	h := newSquare(n)
+	probe := append([]float64(nil), x...)
+	eval := func(i int, di float64, j int, dj float64) float64 {
+		probe[i] += di
+		probe[j] += dj
+		v := f(probe)
+		probe[i] = x[i]
+		probe[j] = x[j]
+		return v
+	}
+	for i := 0; i < n; i++ {
+		for j := i; j < n; j++ {
+			v := (eval(i, hessianStep, j, hessianStep) - eval(i, hessianStep, j, -hessianStep) -
+				eval(i, -hessianStep, j, hessianStep) + eval(i, -hessianStep, j, -hessianStep)) /
+				(4 * hessianStep * hessianStep)
+			h[i][j] = v
+			h[j][i] = v
+		}
+	}
+	return h
+}
+
+func dot(a, b []float64) float64 {
+	sum := 0.0
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
@@ -0,0 +1,145 @@
+package arima
+
+import (
+	"fmt"
+
+	"github.com/DoOR-Team/timeseries_forecasting/arima/matrix"
+)
+
+// Polynomial is a polynomial in the backshift operator B, with p[k] the
+// coefficient of B^k.
+type Polynomial []float64
+
+// Polynomial returns the linear combination sum c_k B^k held by b.
+func (b *BackShift) Polynomial() Polynomial {
+	return Polynomial(b.getCoefficientsFlattened())
+}
+
+// Degree returns the power of the highest non-zero coefficient, or -1 for
+// the zero polynomial.
+func (p Polynomial) Degree() int {
+	for k := len(p) - 1; k >= 0; k-- {
+		if p[k] != 0 {
+			return k
+		}
+	}
+	return -1
+}
+
+// Multiply returns the product of p and q.
+func (p Polynomial) Multiply(q Polynomial) Polynomial {
+	if len(p) == 0 || len(q) == 0 {
+		return Polynomial{}
+	}
+	product := make(Polynomial, len(p)+len(q)-1)
+	for i, a := range p {
+		for j, b := range q {
+			product[i+j] += a * b
+		}
+	}
+	return product
+}
+
+// Evaluate returns the value of p at z.
+func (p Polynomial) Evaluate(z complex128) complex128 {
+	var value complex128
+	for k := len(p) - 1; k >= 0; k-- {
+		value = value*z + complex(p[k], 0)
+	}
+	return value
+}
+
+// Roots returns the complex roots of p, repeated by multiplicity, as the
+// eigenvalues of its companion matrix.
+func (p Polynomial) Roots() ([]complex128, error) {
+	degree := p.Degree()
+	if degree < 0 {
+		return nil, fmt.Errorf("%w: the zero polynomial has no finite set of roots", ErrInvalidOrder)
+	}
+	if degree == 0 {
+		return nil, nil
+	}
+	companion := make([][]float64, degree)
+	for i := range companion {
+		companion[i] = make([]float64, degree)
+		if i > 0 {
+			companion[i][i-1] = 1
+		}
+	}
+	for k := 0; k < degree; k++ {
+		companion[0][k] = -p[degree-1-k] / p[degree]
+	}
+	roots, ok := matrix.NewInsightsMatrixWithData(companion, false).HessenbergEigenvalues()
+	if !ok {
+		return nil, fmt.Errorf("%w: root finding did not converge", ErrSingularSystem)
+	}
+	return roots, nil
+}
+
+// ARPolynomial returns the characteristic AR polynomial 1 - phi_1 B - ...
+// with the current coefficients, including the seasonal lags.
+func (c Config) ARPolynomial() Polynomial {
+	return characteristicPolynomial(c.opAR, -1)
+}
+
+// MAPolynomial returns the characteristic MA polynomial 1 + theta_1 B + ...
+// with the current coefficients, including the seasonal lags.
+func (c Config) MAPolynomial() Polynomial {
+	return characteristicPolynomial(c.opMA, 1)
+}
+
+// differencingPolynomial returns (1 - B)^d (1 - B^m)^D.
+func (c Config) differencingPolynomial() Polynomial {
+	poly := Polynomial{1}
+	for i := 0; i < c.d; i++ {
+		poly = poly.Multiply(Polynomial{1, -1})
+	}
+	if c.m > 0 {
+		seasonal := make(Polynomial, c.m+1)
+		seasonal[0], seasonal[c.m] = 1, -1
+		for i := 0; i < c.D; i++ {
+			poly = poly.Multiply(seasonal)
+		}
+	}
+	return poly
+}
+
+// psiWeights returns the first n weights psi_0 = 1, psi_1, ... of the
+// MA(infinity) representation of the full ARIMA model, whose AR polynomial
+// is multiplied by the differencing operators. For integrated models they
+// do not decay, so forecast variances keep growing with the horizon.
+func (c Config) psiWeights(n int) []float64 {
+	full := c.ARPolynomial().Multiply(c.differencingPolynomial())
+	ar := make([]float64, len(full)-1)
+	for k := 1; k < len(full); k++ {
+		ar[k-1] = -full[k]
+	}
+	_, ma := c.armaCoefficients()
+	return ARMAtoMA(ar, ma, n)
+}
+
+func characteristicPolynomial(op *BackShift, sign float64) Polynomial {
+	poly := Polynomial{1}
+	if terms := op.Polynomial(); len(terms) > 1 {
+		poly = make(Polynomial, len(terms))
+		poly[0] = 1
+		for k := 1; k < len(terms); k++ {
+			if terms[k] != 0 {
+				poly[k] = sign * terms[k]
+			}
+		}
+	}
+	return poly
+}
+
+// ARRoots returns the roots of the estimated AR polynomial. The model is
+// stationary when they all lie outside the unit circle.
+func (m *Model) ARRoots() ([]complex128, error) {
+	return m.Params.ARPolynomial().Roots()
+}
+
+// MARoots returns the roots of the estimated MA polynomial. The model is
+// invertible when they all lie outside the unit circle.
+func (m *Model) MARoots() ([]complex128, error) {
+	return m.Params.MAPolynomial().Roots()
+}
@@ -1,6 +1,12 @@
 package arima
 
-import "math"
+import (
+	"math"
+	"sort"
+
+	"github.com/DoOR-Team/timeseries_forecasting/boxcox"
+	"github.com/DoOR-Team/timeseries_forecasting/distribution"
+)
 
 type Result struct {
 	Forecast              []float64
@@ -9,6 +15,14 @@ type Result struct {
 	dataVariance          float64
 	modelRMSE             float64
 	maxNormalizedVariance float64
+	// innovation standard deviation estimated from the model residuals,
+	// 0 if unknown
+	residualSD float64
+
+	// prediction intervals per confidence level, in the order requested
+	confLevels []float64
+	upperConfs [][]float64
+	lowerConfs [][]float64
 }
 
 func NewResult(pForecast []float64, pDataVariance float64) *Result {
@@ -22,9 +36,7 @@ func NewResult(pForecast []float64, pDataVariance float64) *Result {
 		maxNormalizedVariance: -1,
 	}
 
-	// copy(pForecast, result.forecastUpperConf)
-	copy(result.forecastLowerConf, pForecast)
-	// copy(pForecast, result.forecastUpperConf)
+	copy(result.forecastUpperConf, pForecast)
 	copy(result.forecastLowerConf, pForecast)
 	return result
 }
@@ -54,8 +66,9 @@ func (r *Result) GetMaxNormalizedVariance() float64 {
 func (r *Result) SetConfInterval(constant float64, cumulativeSumOfMA []float64) float64 {
 	maxNormalizedVariance := -1.0
 	bound := 0.
+	scale := r.intervalScale()
 	for i := 0; i < len(r.Forecast); i++ {
-		bound = constant * r.modelRMSE * cumulativeSumOfMA[i]
+		bound = constant * scale * cumulativeSumOfMA[i]
 		r.forecastUpperConf[i] = r.Forecast[i] + bound
 		r.forecastLowerConf[i] = r.Forecast[i] - bound
 		normalizedVariance := r.GetNormalizedVariance(math.Pow(bound, 2))
@@ -66,8 +79,74 @@ func (r *Result) SetConfInterval(constant float64, cumulativeSumOfMA []float64)
 	return maxNormalizedVariance
 }
 
-func (r *Result) SetSigma2AndPredicationInterval(params Config) {
-	r.maxNormalizedVariance = setSigma2AndPredicationInterval(params, r, len(r.Forecast))
+// intervalScale returns the innovation standard deviation that scales the
+// prediction intervals: the residual estimate when there is one, the
+// model RMSE otherwise.
+func (r *Result) intervalScale() float64 {
+	if r.residualSD > 0 {
+		return r.residualSD
+	}
+	return r.modelRMSE
+}
+
+// setConfIntervals computes the prediction interval of every level in
+// levels. The first level also populates GetForecastUpperConf and
+// GetForecastLowerConf.
+func (r *Result) setConfIntervals(levels []float64, cumulativeSumOfMA []float64) float64 {
+	r.confLevels = make([]float64, len(levels))
+	r.upperConfs = make([][]float64, len(levels))
+	r.lowerConfs = make([][]float64, len(levels))
+	maxNormalizedVariance := -1.0
+	for i, level := range levels {
+		normalizedVariance := r.SetConfInterval(levelToConstant(level), cumulativeSumOfMA)
+		if i == 0 {
+			maxNormalizedVariance = normalizedVariance
+		}
+		r.confLevels[i] = level
+		r.upperConfs[i] = append([]float64(nil), r.forecastUpperConf...)
+		r.lowerConfs[i] = append([]float64(nil), r.forecastLowerConf...)
+	}
+	if len(levels) > 0 {
+		copy(r.forecastUpperConf, r.upperConfs[0])
+		copy(r.forecastLowerConf, r.lowerConfs[0])
+	}
+	return maxNormalizedVariance
+}
+
+// setEmpiricalIntervals replaces the prediction intervals with the
+// equal-tailed empirical quantiles of paths, one sample path per slice.
+func (r *Result) setEmpiricalIntervals(levels []float64, paths [][]float64) {
+	r.confLevels = append([]float64(nil), levels...)
+	r.upperConfs = make([][]float64, len(levels))
+	r.lowerConfs = make([][]float64, len(levels))
+	for j := range levels {
+		r.upperConfs[j] = make([]float64, len(r.Forecast))
+		r.lowerConfs[j] = make([]float64, len(r.Forecast))
+	}
+	values := make([]float64, len(paths))
+	for i := range r.Forecast {
+		for k, path := range paths {
+			values[k] = path[i]
+		}
+		sort.Float64s(values)
+		for j, level := range levels {
+			r.lowerConfs[j][i] = distribution.Quantile(values, (1-level)/2)
+			r.upperConfs[j][i] = distribution.Quantile(values, (1+level)/2)
+		}
+	}
+	if len(levels) > 0 {
+		copy(r.forecastUpperConf, r.upperConfs[0])
+		copy(r.forecastLowerConf, r.lowerConfs[0])
+	}
+}
+
+// SetSigma2AndPredicationInterval computes prediction intervals at each of
+// the given confidence levels, 95% if none is given.
+func (r *Result) SetSigma2AndPredicationInterval(params Config, levels ...float64) {
+	if len(levels) == 0 {
+		levels = []float64{defaultConfidenceLevel}
+	}
+	r.maxNormalizedVariance = setPredictionIntervals(params, r, levels)
 }
 
 func (r *Result) GetForecast() []float64 {
@@ -81,3 +160,73 @@ func (r *Result) GetForecastUpperConf() []float64 {
 func (r *Result) GetForecastLowerConf() []float64 {
 	return r.forecastLowerConf
 }
+
+// GetConfLevels returns the confidence levels the Result carries intervals
+// for, in the order they were requested.
+func (r *Result) GetConfLevels() []float64 {
+	return r.confLevels
+}
+
+// GetForecastUpperConfAt returns the upper prediction bound for the given
+// confidence level, or nil if the level was not requested.
+func (r *Result) GetForecastUpperConfAt(level float64) []float64 {
+	if i := r.confLevelIndex(level); i >= 0 {
+		return r.upperConfs[i]
+	}
+	return nil
+}
+
+// GetForecastLowerConfAt returns the lower prediction bound for the given
+// confidence level, or nil if the level was not requested.
+func (r *Result) GetForecastLowerConfAt(level float64) []float64 {
+	if i := r.confLevelIndex(level); i >= 0 {
+		return r.lowerConfs[i]
+	}
+	return nil
+}
+
+func (r *Result) confLevelIndex(level float64) int {
+	for i, l := range r.confLevels {
+		if math.Abs(l-level) < 1e-9 {
+			return i
+		}
+	}
+	return -1
+}
+
+// shift adds offsets to the forecast and every prediction bound.
+func (r *Result) shift(offsets []float64) {
+	for i, offset := range offsets {
+		r.Forecast[i] += offset
+		r.forecastUpperConf[i] += offset
+		r.forecastLowerConf[i] += offset
+		for j := range r.confLevels {
+			r.upperConfs[j][i] += offset
+			r.lowerConfs[j][i] += offset
+		}
+	}
+}
+
+// backTransform maps the forecast and prediction bounds from the Box-Cox
+// scale back to the scale of the data. Quantiles map directly, so only a
+// bias-adjusted forecast needs the forecast variance, which is recovered
+// from the first prediction interval.
+func (r *Result) backTransform(t boxcox.Transform) {
+	if t.BiasAdjust && len(r.confLevels) > 0 {
+		z := levelToConstant(r.confLevels[0])
+		variances := make([]float64, len(r.Forecast))
+		for i := range variances {
+			sd := (r.upperConfs[0][i] - r.Forecast[i]) / z
+			variances[i] = sd * sd
+		}
+		r.Forecast = boxcox.InvertBiasAdjusted(r.Forecast, variances, t.Lambda)
+	} else {
+		r.Forecast = boxcox.Invert(r.Forecast, t.Lambda)
+	}
+	r.forecastUpperConf = boxcox.Invert(r.forecastUpperConf, t.Lambda)
+	r.forecastLowerConf = boxcox.Invert(r.forecastLowerConf, t.Lambda)
+	for j := range r.confLevels {
+		r.upperConfs[j] = boxcox.Invert(r.upperConfs[j], t.Lambda)
+		r.lowerConfs[j] = boxcox.Invert(r.lowerConfs[j], t.Lambda)
+	}
+}
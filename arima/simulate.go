@@ -0,0 +1,135 @@
+package arima
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/DoOR-Team/timeseries_forecasting/arima/matrix"
+	"github.com/DoOR-Team/timeseries_forecasting/boxcox"
+)
+
+// Process holds the coefficients of an ARIMA process for Simulate.
+type Process struct {
+	// AR and MA hold the coefficients spec estimates, in the order listed
+	// by Model.Coefficients: the non-seasonal ones followed by the seasonal
+	// ones, e.g. ar1 and sar1 for p = P = 1. Subset specs take the free
+	// lags in increasing order and keep their fixed coefficients.
+	AR, MA []float64
+	// Sigma is the standard deviation of the Gaussian innovations.
+	// Defaults to 1.
+	Sigma float64
+}
+
+// Simulate draws n points of the ARIMA process described by spec and
+// coefficients, with innovations from src. The ARMA recursion starts from
+// zeros and its first burnIn points are discarded, so burnIn should be
+// large against the lags and the persistence of the AR part. Integration
+// starts from zero levels.
+func Simulate(spec Config, coefficients Process, n, burnIn int, src rand.Source) ([]float64, error) {
+	if n <= 0 || burnIn < 0 {
+		return nil, fmt.Errorf("%w: need n > 0 and burnIn >= 0, got n=%d, burnIn=%d", ErrInvalidOrder, n, burnIn)
+	}
+	params, err := spec.clone()
+	if err != nil {
+		return nil, err
+	}
+	if len(coefficients.AR) != params.getNumParamsP() || len(coefficients.MA) != params.getNumParamsQ() {
+		return nil, fmt.Errorf("%w: spec has %d AR and %d MA coefficients, got %d and %d", ErrInvalidOrder,
+			params.getNumParamsP(), params.getNumParamsQ(), len(coefficients.AR), len(coefficients.MA))
+	}
+	values := append(append([]float64(nil), coefficients.AR...), coefficients.MA...)
+	if !isFinite(values) {
+		return nil, fmt.Errorf("%w: process coefficients", ErrNonFiniteInput)
+	}
+	if len(values) > 0 {
+		params.setParamsFromVector(matrix.NewInsightVectorWithData(values, false))
+	}
+	if ar, _ := params.armaCoefficients(); !isStationaryAR(ar) {
+		return nil, fmt.Errorf("%w: AR polynomial is not stationary", ErrInadmissible)
+	}
+	sigma := coefficients.Sigma
+	if sigma == 0 {
+		sigma = 1
+	}
+	if sigma < 0 || math.IsNaN(sigma) || math.IsInf(sigma, 0) {
+		return nil, fmt.Errorf("%w: innovation standard deviation %v", ErrInvalidOrder, sigma)
+	}
+
+	rng := rand.New(src)
+	total := burnIn + n
+	stationary := make([]float64, total)
+	shocks := make([]float64, total)
+	startIdx := int(math.Max(float64(params.getDegreeP()), float64(params.getDegreeQ())))
+	for t := range stationary {
+		shocks[t] = sigma * rng.NormFloat64()
+		stationary[t] = shocks[t]
+		if t >= startIdx {
+			stationary[t] += params.forecastOnePointARMA(stationary, shocks, t)
+		}
+	}
+	stationary = stationary[burnIn:]
+
+	hasSeasonalI := params.D > 0 && params.m > 0
+	hasNonSeasonalI := params.d > 0
+	if !hasSeasonalI && !hasNonSeasonalI {
+		return stationary, nil
+	}
+	// the initial conditions of a fresh Config are zeros
+	integrated, err := integrate(params, stationary, hasSeasonalI, hasNonSeasonalI)
+	if err != nil {
+		return nil, err
+	}
+	return integrated[len(integrated)-n:], nil
+}
+
+// SimulateFuture draws nPaths sample paths of the h points following the
+// fitted data, conditioned on the observations, with Gaussian innovations
+// of the estimated variance drawn from src. Paths include the mean, drift
+// and Box-Cox back-transformation of the model. Models with exogenous
+// regressors are not supported.
+func (m *Model) SimulateFuture(h, nPaths int, src rand.Source) ([][]float64, error) {
+	if h <= 0 || nPaths <= 0 {
+		return nil, fmt.Errorf("%w: need a positive horizon and number of paths, got h=%d, nPaths=%d",
+			ErrInvalidOrder, h, nPaths)
+	}
+	if m.regression.hasExogenous() {
+		return nil, fmt.Errorf("%w: cannot simulate a model with regressors", ErrInvalidRegressors)
+	}
+	sigma := math.Sqrt(m.sigma2)
+	if math.IsNaN(sigma) {
+		sigma = m.RMSE
+	}
+	rng := rand.New(src)
+	return m.samplePaths(h, nPaths, func() float64 {
+		return sigma * rng.NormFloat64()
+	})
+}
+
+// samplePaths simulates nPaths future paths of h points with innovations
+// from draw, on the scale of the data.
+func (m *Model) samplePaths(h, nPaths int, draw func() float64) ([][]float64, error) {
+	var effect []float64
+	if m.regression != nil {
+		effect = m.regression.effect(nil, m.trainDataSize, h)
+	}
+	paths := make([][]float64, nPaths)
+	for i := range paths {
+		shocks := make([]float64, h)
+		for j := range shocks {
+			shocks[j] = draw()
+		}
+		path, _, err := extendARIMA(m.Params, m.data, m.trainDataSize, m.trainDataSize+h, shocks)
+		if err != nil {
+			return nil, err
+		}
+		for j := range effect {
+			path[j] += effect[j]
+		}
+		if m.boxCox != nil {
+			path = boxcox.Invert(path, m.boxCox.Lambda)
+		}
+		paths[i] = path
+	}
+	return paths, nil
+}
@@ -1,14 +1,15 @@
 package arima
 
 import (
+	"fmt"
 	"math"
 
-	"github.com/DoOR-Team/goutils/log"
 	mtx "github.com/DoOR-Team/timeseries_forecasting/arima/matrix"
 	"github.com/DoOR-Team/timeseries_forecasting/arima/utils"
 )
 
 const maxIterationForHannanRissanen = 5
+const maxIterationsGLS = 10
 
 type Solver struct {
 }
@@ -18,6 +19,13 @@ func newSolver() *Solver {
 }
 
 func forecastARMA(params Config, dataStationary []float64, startIndex int, endIndex int) []float64 {
+	return extendARMA(params, dataStationary, startIndex, endIndex, nil)
+}
+
+// extendARMA continues the stationary series past startIndex up to
+// endIndex, adding shocks[j] as the innovation of the j-th new point; nil
+// shocks give the point forecasts.
+func extendARMA(params Config, dataStationary []float64, startIndex int, endIndex int, shocks []float64) []float64 {
 	trainLen := startIndex
 	totalLen := endIndex
 	errors := make([]float64, totalLen)
@@ -33,58 +41,79 @@ func forecastARMA(params Config, dataStationary []float64, startIndex int, endIn
 	for j := 0; j < startIndex; j++ {
 		errors[j] = 0
 	}
+	fillUnpredictable(data[:trainLen], startIdx)
 
-	// populate errors and forecasts
+	// populate errors and forecasts; a missing value is replaced by its
+	// forecast and has no error
 	for j := startIdx; j < trainLen; j++ {
 		forecast := params.forecastOnePointARMA(data, errors, j)
+		if math.IsNaN(data[j]) {
+			data[j] = forecast
+			continue
+		}
 		dataError := data[j] - forecast
 		errors[j] = dataError
 	}
 	// now we can forecast
 	for j := trainLen; j < totalLen; j++ {
 		forecast := params.forecastOnePointARMA(data, errors, j)
+		if shocks != nil {
+			errors[j] = shocks[j-trainLen]
+			forecast += errors[j]
+		} else {
+			errors[j] = 0
+		}
 		data[j] = forecast
-		errors[j] = 0
 		forecasts[j-trainLen] = forecast
 	}
 	// return forecasted values
 	return forecasts
 }
 
-func forecastARIMA(params Config, data []float64, forecastStartIndex int, forecastEndIndex int) *Result {
-	if !checkARIMADataLength(params, data, forecastStartIndex, forecastEndIndex) {
-		initialConditionSize := params.d + params.D*params.m
-		log.Fatalf(
-			"not enough data for ARIMA. needed at least %d, have %d, startindex=%d, endindex = %d", initialConditionSize,
-			len(data), forecastStartIndex, forecastEndIndex)
+func forecastARIMA(params Config, data []float64, forecastStartIndex int, forecastEndIndex int) (*Result, error) {
+	forecast, dataVariance, err := extendARIMA(params, data, forecastStartIndex, forecastEndIndex, nil)
+	if err != nil {
+		return nil, err
 	}
+	return NewResult(forecast, dataVariance), nil
+}
+
+// extendARIMA continues data past forecastStartIndex up to
+// forecastEndIndex on the original scale, with shocks as the innovations
+// of the new points (nil for point forecasts), and returns the new points
+// with the variance of the stationary series.
+func extendARIMA(params Config, data []float64, forecastStartIndex int, forecastEndIndex int,
+	shocks []float64) ([]float64, float64, error) {
+	if err := checkARIMADataLength(params, data, forecastStartIndex, forecastEndIndex); err != nil {
+		return nil, 0, err
+	}
+	params = params.withOwnBuffers()
 
 	forecast_length := forecastEndIndex - forecastStartIndex
 	forecast := make([]float64, forecast_length)
-	data_train := make([]float64, forecastStartIndex)
-	// copy(data, data_train)
-	copy(data_train, data)
+	// missing values are imputed on the original scale, so the levels the
+	// forecast is integrated from stay consistent with the observations
+	data_train := imputeMissing(params, data[:forecastStartIndex])
 
 	// DIFFERENTIATE
 	hasSeasonalI := params.D > 0 && params.m > 0
 	hasNonSeasonalI := params.d > 0
-	data_stationary := differentiate(params, data_train, hasSeasonalI,
-		hasNonSeasonalI) // currently un-centered
+	// the constant, if any, was regressed out before differencing
+	data_stationary, err := differentiate(params, data_train, hasSeasonalI,
+		hasNonSeasonalI)
+	if err != nil {
+		return nil, 0, err
+	}
+	dataVariance := utils.ComputeVariance(data_stationary)
 
 	// END OF DIFFERENTIATE
 	// ==========================================
 
-	// =========== CENTERING ====================
-	mean_stationary := utils.ComputeMean(data_stationary)
-	utils.Shift(data_stationary, (-1)*mean_stationary)
-	dataVariance := utils.ComputeVariance(data_stationary)
-	// ==========================================
-
 	// ==========================================
 	// FORECAST
-	forecast_stationary := forecastARMA(params, data_stationary,
+	forecast_stationary := extendARMA(params, data_stationary,
 		len(data_stationary),
-		len(data_stationary)+forecast_length)
+		len(data_stationary)+forecast_length, shocks)
 
 	data_forecast_stationary := make([]float64, len(data_stationary)+forecast_length)
 	// copy(data_stationary, data_forecast_stationary)
@@ -95,27 +124,24 @@ func forecastARIMA(params Config, data []float64, forecastStartIndex int, foreca
 	// END OF FORECAST
 	// ==========================================
 
-	// =========== UN-CENTERING =================
-	utils.Shift(data_forecast_stationary, mean_stationary)
-	// ==========================================
-
 	// ===========================================
 	// INTEGRATE
-	forecast_merged := integrate(params, data_forecast_stationary, hasSeasonalI,
+	forecast_merged, err := integrate(params, data_forecast_stationary, hasSeasonalI,
 		hasNonSeasonalI)
+	if err != nil {
+		return nil, 0, err
+	}
 	// END OF INTEGRATE
 	// ===========================================
 	copy(forecast, forecast_merged[forecastStartIndex:])
 
-	return NewResult(forecast, dataVariance)
+	return forecast, dataVariance, nil
 }
 
-func estimateARIMA(params Config, data []float64, forecastStartIndex int, forecastEndIndex int) *Model {
-	if !checkARIMADataLength(params, data, forecastStartIndex, forecastEndIndex) {
-		initialConditionSize := params.d + params.D*params.m
-		log.Fatalf(
-			"not enough data for ARIMA. needed at least %d, have %d, startindex=%d, endindex = %d", initialConditionSize,
-			len(data), forecastStartIndex, forecastEndIndex)
+func estimateARIMA(params Config, data []float64, forecastStartIndex int, forecastEndIndex int,
+	method Method, admissibility Admissibility) (*Model, error) {
+	if err := checkARIMADataLength(params, data, forecastStartIndex, forecastEndIndex); err != nil {
+		return nil, err
 	}
 	forecast_length := forecastEndIndex - forecastStartIndex
 	data_train := make([]float64, forecastStartIndex)
@@ -123,34 +149,223 @@ func estimateARIMA(params Config, data []float64, forecastStartIndex int, foreca
 	copy(data_train, data)
 	hasSeasonalI := params.D > 0 && params.m > 0
 	hasNonSeasonalI := params.d > 0
-	data_stationary := differentiate(params, data_train, hasSeasonalI,
-		hasNonSeasonalI) // currently un-centered
+	// the constant, if any, was regressed out before differencing
+	data_stationary, err := differentiate(params, data_train, hasSeasonalI,
+		hasNonSeasonalI)
+	if err != nil {
+		return nil, err
+	}
 	// END OF DIFFERENTIATE
 	// ==========================================
+	// ESTIMATE
+	model := &Model{
+		Params:        params,
+		data:          data,
+		trainDataSize: forecastStartIndex,
+		stationary:    data_stationary,
+		method:        method,
+	}
+	switch method {
+	case MethodML:
+		result, err := estimateML(data_stationary, &params, nil)
+		if err != nil {
+			return nil, err
+		}
+		model.setExactLikelihood(result)
+	case MethodCSS:
+		if err := estimateCSS(data_stationary, &params); err != nil {
+			return nil, err
+		}
+		model.setLikelihood(params)
+	case MethodCSSML:
+		if err := estimateCSS(data_stationary, &params); err != nil {
+			return nil, err
+		}
+		var start []float64
+		if params.getNumParamsP()+params.getNumParamsQ() > 0 {
+			cssParams := params.getParamsIntoVector()
+			start = cssParams.DeepCopy()
+		}
+		result, err := estimateML(data_stationary, &params, start)
+		if err != nil {
+			return nil, err
+		}
+		model.setExactLikelihood(result)
+	default:
+		if err := estimateARMA(data_stationary, &params, forecast_length,
+			maxIterationForHannanRissanen); err != nil {
+			return nil, err
+		}
+		if model.correction, err = enforceAdmissible(params, admissibility); err != nil {
+			return nil, err
+		}
+		model.setLikelihood(params)
+	}
+	return model, nil
+}
 
-	// =========== CENTERING ====================
-	mean_stationary := utils.ComputeMean(data_stationary)
-	utils.Shift(data_stationary, (-1)*mean_stationary)
-	// ==========================================
-	// FORECAST
-	estimateARMA(data_stationary, &params, forecast_length,
-		maxIterationForHannanRissanen)
+// computeResiduals returns the one-step-ahead errors of the ARMA part on the
+// stationary series. Errors before the first lag that can be
+// predicted and at missing values are NaN; the recursion continues with
+// each missing value replaced by its prediction.
+func computeResiduals(params Config, dataStationary []float64) []float64 {
+	residuals, _ := conditionalResiduals(params, dataStationary)
+	return residuals
+}
+
+// conditionalResiduals returns computeResiduals together with the last
+// max(p, q) points of the recursion, from which it can be continued.
+func conditionalResiduals(params Config, dataStationary []float64) ([]float64, armaTail) {
+	data := append([]float64(nil), dataStationary...)
+	residuals := make([]float64, len(data))
+	errors := make([]float64, len(data))
+	startIdx := int(math.Max(float64(params.getDegreeP()), float64(params.getDegreeQ())))
+	fillUnpredictable(data, startIdx)
+	for j := 0; j < len(data); j++ {
+		if j < startIdx {
+			residuals[j] = math.NaN()
+			continue
+		}
+		forecast := params.forecastOnePointARMA(data, errors, j)
+		if math.IsNaN(data[j]) {
+			data[j] = forecast
+			residuals[j] = math.NaN()
+			continue
+		}
+		errors[j] = data[j] - forecast
+		residuals[j] = errors[j]
+	}
+	from := len(data) - startIdx
+	if from < 0 {
+		from = 0
+	}
+	tail := armaTail{
+		data:   append([]float64(nil), data[from:]...),
+		errors: append([]float64(nil), errors[from:]...),
+	}
+	return residuals, tail
+}
 
-	return &Model{Params: params, data: data, trainDataSize: forecastStartIndex}
+// armaTail holds the last max(p, q) points of the conditional ARMA
+// recursion: the stationary values, with missing ones replaced by their
+// predictions, and the one-step errors.
+type armaTail struct {
+	data   []float64
+	errors []float64
+}
+
+// next continues the recursion with the stationary value x. It returns the
+// residual of x, NaN where computeResiduals has one, and the new tail; t
+// is not changed.
+func (t armaTail) next(params Config, x float64) (float64, armaTail) {
+	startIdx := int(math.Max(float64(params.getDegreeP()), float64(params.getDegreeQ())))
+	j := len(t.data)
+	data := append(append(make([]float64, 0, j+1), t.data...), x)
+	errors := append(append(make([]float64, 0, j+1), t.errors...), 0)
+	residual := math.NaN()
+	if j < startIdx {
+		fillUnpredictable(data, startIdx)
+	} else {
+		forecast := params.forecastOnePointARMA(data, errors, j)
+		if math.IsNaN(x) {
+			data[j] = forecast
+		} else {
+			errors[j] = x - forecast
+			residual = errors[j]
+		}
+	}
+	if from := len(data) - startIdx; from > 0 {
+		data, errors = data[from:], errors[from:]
+	}
+	return residual, armaTail{data: data, errors: errors}
+}
+
+// fillUnpredictable sets missing values among the first startIdx points of
+// a stationary series, which the ARMA recursion cannot predict, to the
+// process mean of zero.
+func fillUnpredictable(data []float64, startIdx int) {
+	for j := 0; j < startIdx && j < len(data); j++ {
+		if math.IsNaN(data[j]) {
+			data[j] = 0
+		}
+	}
+}
+
+// imputeMissing returns a copy of data, the undifferenced series, with each
+// missing value replaced by its one-step prediction from params, treating
+// earlier imputations as observed. Missing values among the first d+D*m
+// points, which the model cannot predict, take the nearest earlier
+// observation, or the first one.
+func imputeMissing(params Config, data []float64) []float64 {
+	filled := append([]float64(nil), data...)
+	if missing, _ := countMissing(filled); missing == 0 {
+		return filled
+	}
+	delta := params.differencingPolynomial()
+	initial := len(delta) - 1
+	for t := 0; t < initial && t < len(filled); t++ {
+		if !math.IsNaN(filled[t]) {
+			continue
+		}
+		if t > 0 {
+			filled[t] = filled[t-1]
+			continue
+		}
+		for _, v := range data {
+			if !math.IsNaN(v) {
+				filled[t] = v
+				break
+			}
+		}
+	}
+	if len(filled) <= initial {
+		return filled
+	}
+
+	stationary := make([]float64, len(filled)-initial)
+	errors := make([]float64, len(stationary))
+	startIdx := int(math.Max(float64(params.getDegreeP()), float64(params.getDegreeQ())))
+	for t := initial; t < len(filled); t++ {
+		i := t - initial
+		lagged := 0.0
+		for k := 1; k < len(delta); k++ {
+			lagged += delta[k] * filled[t-k]
+		}
+		if !math.IsNaN(filled[t]) {
+			stationary[i] = filled[t] + lagged
+			if i >= startIdx {
+				errors[i] = stationary[i] - params.forecastOnePointARMA(stationary, errors, i)
+			}
+			continue
+		}
+		if i >= startIdx {
+			stationary[i] = params.forecastOnePointARMA(stationary, errors, i)
+		}
+		filled[t] = stationary[i] - lagged
+	}
+	return filled
 }
 
 func differentiate(params Config, trainingData []float64,
-	hasSeasonalI bool, hasNonSeasonalI bool) []float64 {
+	hasSeasonalI bool, hasNonSeasonalI bool) ([]float64, error) {
 	var dataStationary []float64 // currently un-centered
 	if hasSeasonalI && hasNonSeasonalI {
-		params.differentiateSeasonal(trainingData)
-		params.differentiateNonSeasonal(params.getLastDifferenceSeasonal())
+		if err := params.differentiateSeasonal(trainingData); err != nil {
+			return nil, err
+		}
+		if err := params.differentiateNonSeasonal(params.getLastDifferenceSeasonal()); err != nil {
+			return nil, err
+		}
 		dataStationary = params.getLastDifferenceNonSeasonal()
 	} else if hasSeasonalI {
-		params.differentiateSeasonal(trainingData)
+		if err := params.differentiateSeasonal(trainingData); err != nil {
+			return nil, err
+		}
 		dataStationary = params.getLastDifferenceSeasonal()
 	} else if hasNonSeasonalI {
-		params.differentiateNonSeasonal(trainingData)
+		if err := params.differentiateNonSeasonal(trainingData); err != nil {
+			return nil, err
+		}
 		dataStationary = params.getLastDifferenceNonSeasonal()
 	} else {
 		dataStationary = make([]float64, len(trainingData))
@@ -158,21 +373,29 @@ func differentiate(params Config, trainingData []float64,
 		copy(dataStationary, trainingData)
 	}
 
-	return dataStationary
+	return dataStationary, nil
 }
 
 func integrate(params Config, dataForecastStationary []float64,
-	hasSeasonalI bool, hasNonSeasonalI bool) []float64 {
+	hasSeasonalI bool, hasNonSeasonalI bool) ([]float64, error) {
 	var forecastMerged []float64
 	if hasSeasonalI && hasNonSeasonalI {
-		params.getIntegrateSeasonal(dataForecastStationary)
-		params.getIntegrateNonSeasonal(params.getLastIntegrateSeasonal())
+		if err := params.getIntegrateSeasonal(dataForecastStationary); err != nil {
+			return nil, err
+		}
+		if err := params.getIntegrateNonSeasonal(params.getLastIntegrateSeasonal()); err != nil {
+			return nil, err
+		}
 		forecastMerged = params.getLastIntegrateNonSeasonal()
 	} else if hasSeasonalI {
-		params.getIntegrateSeasonal(dataForecastStationary)
+		if err := params.getIntegrateSeasonal(dataForecastStationary); err != nil {
+			return nil, err
+		}
 		forecastMerged = params.getLastIntegrateSeasonal()
 	} else if hasNonSeasonalI {
-		params.getIntegrateNonSeasonal(dataForecastStationary)
+		if err := params.getIntegrateNonSeasonal(dataForecastStationary); err != nil {
+			return nil, err
+		}
 		forecastMerged = params.getLastIntegrateNonSeasonal()
 	} else {
 		forecastMerged = make([]float64, len(dataForecastStationary))
@@ -180,60 +403,78 @@ func integrate(params Config, dataForecastStationary []float64,
 		copy(forecastMerged, dataForecastStationary)
 	}
 
-	return forecastMerged
+	return forecastMerged, nil
 }
 
 func computeRMSE(left []float64, right []float64,
-	leftIndexOffset, startIndex, endIndex int) float64 {
+	leftIndexOffset, startIndex, endIndex int) (float64, error) {
 
 	len_left := len(left)
 	len_right := len(right)
 	if startIndex >= endIndex || startIndex < 0 || len_right < endIndex ||
 		len_left+leftIndexOffset < 0 || len_left+leftIndexOffset < endIndex {
-		log.Fatalf("invalid arguments: startIndex=%d, endIndex=%d, len_left=%d, len_right=%d, leftOffset=%d",
-			startIndex, endIndex, len_left, len_right, leftIndexOffset)
+		return 0, fmt.Errorf("%w: startIndex=%d, endIndex=%d, len_left=%d, len_right=%d, leftOffset=%d",
+			ErrInsufficientData, startIndex, endIndex, len_left, len_right, leftIndexOffset)
 	}
 	square_sum := 0.0
+	observed := 0
 	for i := startIndex; i < endIndex; i++ {
 		dataerror := left[i+leftIndexOffset] - right[i]
+		if math.IsNaN(dataerror) {
+			// missing observation
+			continue
+		}
 		square_sum += dataerror * dataerror
+		observed++
 	}
-	return math.Sqrt(square_sum / float64(endIndex-startIndex))
+	if observed == 0 {
+		// every value is missing
+		return math.NaN(), nil
+	}
+	return math.Sqrt(square_sum / float64(observed)), nil
 }
 
 func computeRMSEValidation(data []float64,
-	testDataPercentage float64, params Config) float64 {
+	testDataPercentage float64, params Config, method Method, admissibility Admissibility) (float64, error) {
 
 	testDataLength := int(float64(len(data)) * testDataPercentage)
 	trainingDataEndIndex := len(data) - testDataLength
 
-	result := estimateARIMA(params, data, trainingDataEndIndex, len(data))
+	result, err := estimateARIMA(params, data, trainingDataEndIndex, len(data), method, admissibility)
+	if err != nil {
+		return 0, err
+	}
 
-	forecast := result.forecast(testDataLength).GetForecast()
+	forecastResult, err := result.forecast(testDataLength)
+	if err != nil {
+		return 0, err
+	}
+	forecast := forecastResult.GetForecast()
 
-	return computeRMSE(data, forecast, trainingDataEndIndex, 0, len(forecast))
+	rmse, err := computeRMSE(data, forecast, trainingDataEndIndex, 0, len(forecast))
+	if err == nil && math.IsNaN(rmse) {
+		err = fmt.Errorf("%w: every validation value is missing", ErrInsufficientData)
+	}
+	return rmse, err
 }
 
-func setSigma2AndPredicationInterval(params Config,
-	forecastResult *Result, forecastSize int) float64 {
+func setPredictionIntervals(params Config,
+	forecastResult *Result, levels []float64) float64 {
 
-	coeffs_AR := params.getCurrentARCoefficients()
-	coeffs_MA := params.getCurrentMACoefficients()
-	return forecastResult.SetConfInterval(confidence_constant_95pct,
-		getCumulativeSumOfCoeff(
-			ARMAtoMA(coeffs_AR, coeffs_MA, forecastSize)))
+	return forecastResult.setConfIntervals(levels,
+		getCumulativeSumOfCoeff(params.psiWeights(len(forecastResult.Forecast))))
 }
 
-func checkARIMADataLength(params Config, data []float64, startIndex, endIndex int) bool {
-	result := true
-
+func checkARIMADataLength(params Config, data []float64, startIndex, endIndex int) error {
 	initialConditionSize := int(params.d + params.D*params.m)
 
 	if len(data) < initialConditionSize || startIndex < initialConditionSize || endIndex <= startIndex {
-		result = false
+		return fmt.Errorf(
+			"%w: not enough data for ARIMA. needed at least %d, have %d, startindex=%d, endindex = %d",
+			ErrInsufficientData, initialConditionSize, len(data), startIndex, endIndex)
 	}
 
-	return result
+	return nil
 }
 
 /**
@@ -241,7 +482,11 @@ func checkARIMADataLength(params Config, data []float64, startIndex, endIndex in
  */
 
 func estimateARMA(data_orig []float64, params *Config,
-	forecast_length, maxIteration int) {
+	forecast_length, maxIteration int) error {
+	if params.getNumParamsP()+params.getNumParamsQ() == 0 {
+		// white noise around the mean, nothing to estimate
+		return nil
+	}
 	data := make([]float64, len(data_orig))
 	total_length := len(data)
 	// copy(data_orig, data)
@@ -250,20 +495,22 @@ func estimateARMA(data_orig []float64, params *Config,
 	length := total_length - forecast_length
 	size := length - r
 	if length < (2 * r) {
-		log.Fatalf("not enough data points: length= %d, r=", length, r)
+		return fmt.Errorf("%w: not enough data points: length= %d, r= %d", ErrInsufficientData, length, r)
 	}
 
 	// step 1: apply Yule-Walker method and estimate AR(r) model on input data
 	errors := make([]float64, length)
 	// yuleWalkerParams := applyYuleWalkerAndGetInitialErrors(data, r, length, errors)
-	_ = applyYuleWalkerAndGetInitialErrors(data, r, length, errors)
+	if _, err := applyYuleWalkerAndGetInitialErrors(data, r, length, errors); err != nil {
+		return err
+	}
 	for j := 0; j < r; j++ {
 		errors[j] = 0
 	}
 
 	// step 2: iterate Least-Square fitting until the parameters converge
 	// instantiate Z-matrix
-	matrix := make([][]float64, params.getNumParamsP()+params.getNumParamsQ())
+	matrix := make([][]float64, len(params.getOffsetsAR())+len(params.getOffsetsMA()))
 	for i, _ := range matrix {
 		matrix[i] = make([]float64, size)
 	}
@@ -272,19 +519,30 @@ func estimateARMA(data_orig []float64, params *Config,
 	remainIteration := maxIteration
 	var bestParams *mtx.InsightsVector
 	for remainIteration >= 0 {
-		estimatedParams := iterationStep(*params, data, errors, matrix, r,
+		estimatedParams, err := iterationStep(*params, data, errors, matrix, r,
 			length,
 			size)
-		// originalParams := params.getParamsIntoVector()
-		params.setParamsFromVector(estimatedParams)
+		if err != nil {
+			return err
+		}
+		if estimatedParams == nil || !isFinite(estimatedParams.DeepCopy()) {
+			return fmt.Errorf("%w: Hannan-Rissanen least squares step", ErrSingularSystem)
+		}
+		params.setLagCoefficients(estimatedParams)
 
 		// forecast for validation data and compute RMSE
 		forecasts := forecastARMA(*params, data, length, len(data))
-		anotherRMSE := computeRMSE(data, forecasts, length, 0, forecast_length)
+		anotherRMSE, err := computeRMSE(data, forecasts, length, 0, forecast_length)
+		if err != nil {
+			return err
+		}
 		// update errors
 		train_forecasts := forecastARMA(*params, data, r, len(data))
 		for j := 0; j < size; j++ {
 			errors[j+r] = data[j+r] - train_forecasts[j]
+			if math.IsNaN(errors[j+r]) {
+				errors[j+r] = 0
+			}
 		}
 		if bestRMSE < 0 || anotherRMSE < bestRMSE {
 			bestParams = estimatedParams
@@ -292,12 +550,19 @@ func estimateARMA(data_orig []float64, params *Config,
 		}
 		remainIteration--
 	}
-	params.setParamsFromVector(bestParams)
+	params.setLagCoefficients(bestParams)
+	return nil
 }
 
-func applyYuleWalkerAndGetInitialErrors(data []float64, r, length int, errors []float64) []float64 {
-	yuleWalker := Fit(data, r)
-	bsYuleWalker := NewBackShift(r, true)
+func applyYuleWalkerAndGetInitialErrors(data []float64, r, length int, errors []float64) ([]float64, error) {
+	yuleWalker, err := FitYuleWalker(data, r)
+	if err != nil {
+		return nil, err
+	}
+	bsYuleWalker, err := NewBackShift(r, true)
+	if err != nil {
+		return nil, err
+	}
 	bsYuleWalker.initializeParams(false)
 	// return array from YuleWalker is an array of size r whose
 	// 0-th index element is lag 1 coefficient etc
@@ -316,15 +581,19 @@ func applyYuleWalkerAndGetInitialErrors(data []float64, r, length int, errors []
 		// from then on, initial estimate of error terms are
 		// Z_t = X_t - \phi_1 X_{t-1} - \cdots - \phi_r X_{t-r}
 		errors[m] = data[m] - bsYuleWalker.getLinearCombinationFrom(data, m)
+		if math.IsNaN(errors[m]) {
+			// missing value or lag
+			errors[m] = 0
+		}
 		m++
 	}
-	return yuleWalker
+	return yuleWalker, nil
 }
 
 func iterationStep(
 	params Config,
 	data []float64, errors []float64,
-	matrix [][]float64, r, length, size int) *mtx.InsightsVector {
+	matrix [][]float64, r, length, size int) (*mtx.InsightsVector, error) {
 
 	rowIdx := 0
 	// copy over shifted timeseries data into matrix
@@ -342,13 +611,26 @@ func iterationStep(
 		rowIdx++
 	}
 
-	// instantiate matrix to perform least squares algorithm
-	zt := mtx.NewInsightsMatrixWithData(matrix, false)
-
-	// instantiate target vector
+	// instantiate target vector, less the part explained by fixed
+	// coefficients
 	vector := make([]float64, size)
 	// copy(data[r:], vector[:size])
 	copy(vector[:size], data[r:])
+	if params.isSubset() {
+		for i := range vector {
+			vector[i] -= params.fixedContribution(data, errors, r+i)
+		}
+	}
+
+	// drop the rows with missing values
+	columns, vector := completeCases(matrix, vector)
+	if len(vector) < len(columns) {
+		return nil, fmt.Errorf("%w: %d complete rows for %d Hannan-Rissanen parameters",
+			ErrInsufficientData, len(vector), len(columns))
+	}
+
+	// instantiate matrix to perform least squares algorithm
+	zt := mtx.NewInsightsMatrixWithData(columns, false)
 	x := mtx.NewInsightVectorWithData(vector, false)
 
 	// obtain least squares solution
@@ -356,5 +638,5 @@ func iterationStep(
 	ztz := zt.ComputeAAT()
 	estimatedVector := ztz.SolveSPDIntoVector(ztx, maxConditionNumber)
 
-	return estimatedVector
+	return estimatedVector, nil
 }
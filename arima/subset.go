@@ -0,0 +1,153 @@
+package arima
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Lags lists the lags of a subset AR or MA polynomial, e.g. 1, 2, 24 and
+// 168 for hourly data. Fixed holds coefficients that are not estimated,
+// keyed by lag; every key must be one of Lags.
+type Lags struct {
+	Lags  []int
+	Fixed map[int]float64
+}
+
+// NewSubsetConfig returns the configuration of a subset ARIMA model whose
+// AR and MA polynomials only have the lags of ar and ma, after d
+// non-seasonal and D seasonal differences of period m. Seasonal AR and MA
+// terms are given as explicit lags. Only the coefficients that are not
+// fixed are estimated, and only they count as parameters.
+func NewSubsetConfig(ar Lags, d int, ma Lags, D, m int) (Config, error) {
+	lagsAR, fixedAR, err := ar.resolve("AR")
+	if err != nil {
+		return Config{}, err
+	}
+	lagsMA, fixedMA, err := ma.resolve("MA")
+	if err != nil {
+		return Config{}, err
+	}
+	config, err := NewConfig(0, d, 0, 0, D, 0, m)
+	if err != nil {
+		return Config{}, err
+	}
+	if config.opAR, err = subsetBackShift(lagsAR, fixedAR); err != nil {
+		return Config{}, err
+	}
+	if config.opMA, err = subsetBackShift(lagsMA, fixedMA); err != nil {
+		return Config{}, err
+	}
+	config.lagsAR, config.paramsAR = lagsAR, fixedAR
+	config.lagsMA, config.paramsMA = lagsMA, fixedMA
+	config.p = config.opAR.getDegree()
+	config.q = config.opMA.getDegree()
+	config.dp = config.opAR.getDegree()
+	config.dq = config.opMA.getDegree()
+	config.np = len(config.getOffsetsAR())
+	config.nq = len(config.getOffsetsMA())
+	return config, nil
+}
+
+// resolve validates l and returns its lags in increasing order with the
+// fixed value of each, NaN where the coefficient is estimated.
+func (l Lags) resolve(name string) (lags []int, fixed []float64, err error) {
+	lags = append([]int(nil), l.Lags...)
+	sort.Ints(lags)
+	for i, lag := range lags {
+		if lag < 1 {
+			return nil, nil, fmt.Errorf("%w: %s lags must be positive, got %d", ErrInvalidOrder, name, lag)
+		}
+		if i > 0 && lags[i-1] == lag {
+			return nil, nil, fmt.Errorf("%w: duplicate %s lag %d", ErrInvalidOrder, name, lag)
+		}
+	}
+	fixed = make([]float64, len(lags))
+	for i := range fixed {
+		fixed[i] = math.NaN()
+	}
+	for lag, value := range l.Fixed {
+		i := sort.SearchInts(lags, lag)
+		if i == len(lags) || lags[i] != lag {
+			return nil, nil, fmt.Errorf("%w: fixed %s coefficient at lag %d, which is not in the lags",
+				ErrInvalidOrder, name, lag)
+		}
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return nil, nil, fmt.Errorf("%w: fixed %s coefficient at lag %d", ErrNonFiniteInput, name, lag)
+		}
+		fixed[i] = value
+	}
+	return lags, fixed, nil
+}
+
+// subsetBackShift returns the operator with coefficients at lags, set to
+// the fixed values that are not NaN.
+func subsetBackShift(lags []int, fixed []float64) (*BackShift, error) {
+	degree := 0
+	if len(lags) > 0 {
+		degree = lags[len(lags)-1]
+	}
+	op, err := NewBackShift(degree, false)
+	if err != nil {
+		return nil, err
+	}
+	for _, lag := range lags {
+		op.setIndex(lag, true)
+	}
+	op.initializeParams(false)
+	for i, lag := range lags {
+		if !math.IsNaN(fixed[i]) {
+			op.setParam(lag, fixed[i])
+		}
+	}
+	return op, nil
+}
+
+// subsetLags returns the Lags that resolve to lags and fixed.
+func subsetLags(lags []int, fixed []float64) Lags {
+	l := Lags{Lags: lags, Fixed: make(map[int]float64)}
+	for i, lag := range lags {
+		if !math.IsNaN(fixed[i]) {
+			l.Fixed[lag] = fixed[i]
+		}
+	}
+	return l
+}
+
+// isSubset reports whether c was built by NewSubsetConfig.
+func (c Config) isSubset() bool {
+	return c.lagsAR != nil || c.lagsMA != nil
+}
+
+// freeOffsets returns the lags of op whose coefficients are estimated,
+// given the lags and fixed values of a subset polynomial.
+func freeOffsets(op *BackShift, lags []int, fixed []float64) []int {
+	offsets := op.paramOffsets()
+	if fixed == nil {
+		return offsets
+	}
+	free := make([]int, 0, len(offsets))
+	for i, lag := range lags {
+		if math.IsNaN(fixed[i]) {
+			free = append(free, lag)
+		}
+	}
+	return free
+}
+
+// fixedContribution returns the part of the ARMA prediction at index that
+// comes from fixed coefficients.
+func (c Config) fixedContribution(data, errors []float64, index int) float64 {
+	sum := 0.0
+	for i, lag := range c.lagsAR {
+		if !math.IsNaN(c.paramsAR[i]) {
+			sum += c.paramsAR[i] * data[index-lag]
+		}
+	}
+	for i, lag := range c.lagsMA {
+		if !math.IsNaN(c.paramsMA[i]) {
+			sum += c.paramsMA[i] * errors[index-lag]
+		}
+	}
+	return sum
+}
@@ -0,0 +1,148 @@
+package tests
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/DoOR-Team/timeseries_forecasting/arima/utils"
+)
+
+// mackinnonCriticalValues are the response surface coefficients of
+// MacKinnon (2010), table 2, for one variable: the critical value for n
+// observations is c[0] + c[1]/n + c[2]/n^2 + c[3]/n^3.
+var mackinnonCriticalValues = map[Deterministic]map[float64][4]float64{
+	Level: {
+		0.01: {-3.43035, -6.5393, -16.786, -79.433},
+		0.05: {-2.86154, -2.8903, -4.234, -40.040},
+		0.10: {-2.56677, -1.5384, -2.809, 0},
+	},
+	Trend: {
+		0.01: {-3.95877, -9.0531, -28.428, -134.155},
+		0.05: {-3.41049, -4.3904, -9.036, -45.374},
+		0.10: {-3.12705, -2.5856, -3.925, -22.380},
+	},
+}
+
+// mackinnonPValueCoefficients approximate the asymptotic distribution of
+// the Dickey-Fuller statistic, from MacKinnon (1994): below star the
+// p-value is Phi(small[0] + small[1] s + small[2] s^2), above it the large
+// polynomial is used, and outside [min, max] it is 0 or 1.
+var mackinnonPValueCoefficients = map[Deterministic]struct {
+	min, star, max float64
+	small          []float64
+	large          []float64
+}{
+	Level: {-18.83, -1.61, 2.74,
+		[]float64{2.1659, 1.4412, 0.038269},
+		[]float64{1.7339, 0.93202, -0.12745, -0.010368}},
+	Trend: {-16.18, -2.89, 0.7,
+		[]float64{3.2512, 1.6047, 0.049588},
+		[]float64{2.5261, 0.61654, -0.37956, -0.060285}},
+}
+
+// ADF runs the augmented Dickey-Fuller test. The null hypothesis is a unit
+// root, so small (very negative) statistics suggest the series is
+// stationary. The number of lagged differences is chosen by AIC between 0
+// and maxLags; maxLags < 0 selects trunc(12*(n/100)^0.25).
+func ADF(data []float64, deterministic Deterministic, maxLags int) (*Result, error) {
+	if _, ok := mackinnonCriticalValues[deterministic]; !ok {
+		return nil, fmt.Errorf("%w: deterministic terms %d", ErrInvalidArgument, deterministic)
+	}
+	n := len(data)
+	numTrend := len(deterministicColumns(deterministic, 0, 0))
+	if maxLags < 0 {
+		maxLags = int(12 * math.Pow(float64(n)/100, 0.25))
+	}
+	if limit := n/2 - numTrend - 1; maxLags > limit {
+		maxLags = limit
+	}
+	if maxLags < 0 {
+		return nil, fmt.Errorf("%w: ADF needs at least %d points, have %d",
+			ErrInsufficientData, 2*(numTrend+1), n)
+	}
+
+	// select the lags on the sample common to every candidate
+	bestLags, bestAIC := 0, math.Inf(1)
+	for lags := 0; lags <= maxLags; lags++ {
+		_, _, residuals, err := ols(adfRegression(data, deterministic, lags, maxLags+1))
+		if err != nil {
+			return nil, err
+		}
+		squareSum := 0.0
+		for _, e := range residuals {
+			squareSum += e * e
+		}
+		m := float64(len(residuals))
+		k := float64(numTrend + 1 + lags)
+		if aic := m*math.Log(squareSum/m) + 2*k; aic < bestAIC {
+			bestLags, bestAIC = lags, aic
+		}
+	}
+
+	beta, stdErr, residuals, err := ols(adfRegression(data, deterministic, bestLags, bestLags+1))
+	if err != nil {
+		return nil, err
+	}
+	statistic := beta[numTrend] / stdErr[numTrend]
+	return &Result{
+		Statistic:      statistic,
+		Lags:           bestLags,
+		CriticalValues: dickeyFullerCriticalValues(deterministic, len(residuals)),
+		PValue:         dickeyFullerPValue(deterministic, statistic),
+	}, nil
+}
+
+// adfRegression returns the regressors and response of the ADF regression
+// of diff y[t] on the deterministic terms, y[t-1] and diff y[t-1], ...,
+// diff y[t-lags], for t from start.
+func adfRegression(data []float64, deterministic Deterministic, lags, start int) ([][]float64, []float64) {
+	n := len(data) - start
+	columns := deterministicColumns(deterministic, start, n)
+	lagged := make([]float64, n)
+	response := make([]float64, n)
+	for t := start; t < len(data); t++ {
+		lagged[t-start] = data[t-1]
+		response[t-start] = data[t] - data[t-1]
+	}
+	columns = append(columns, lagged)
+	for l := 1; l <= lags; l++ {
+		column := make([]float64, n)
+		for t := start; t < len(data); t++ {
+			column[t-start] = data[t-l] - data[t-l-1]
+		}
+		columns = append(columns, column)
+	}
+	return columns, response
+}
+
+// dickeyFullerCriticalValues returns the finite-sample critical values of
+// the Dickey-Fuller statistic for nobs observations.
+func dickeyFullerCriticalValues(deterministic Deterministic, nobs int) map[float64]float64 {
+	n := float64(nobs)
+	criticalValues := make(map[float64]float64)
+	for level, c := range mackinnonCriticalValues[deterministic] {
+		criticalValues[level] = c[0] + c[1]/n + c[2]/(n*n) + c[3]/(n*n*n)
+	}
+	return criticalValues
+}
+
+// dickeyFullerPValue returns the approximate asymptotic p-value of a
+// Dickey-Fuller statistic.
+func dickeyFullerPValue(deterministic Deterministic, statistic float64) float64 {
+	c := mackinnonPValueCoefficients[deterministic]
+	switch {
+	case statistic > c.max:
+		return 1
+	case statistic < c.min:
+		return 0
+	}
+	coefficients := c.large
+	if statistic <= c.star {
+		coefficients = c.small
+	}
+	value := 0.0
+	for k := len(coefficients) - 1; k >= 0; k-- {
+		value = value*statistic + coefficients[k]
+	}
+	return utils.NormalCDF(value)
+}
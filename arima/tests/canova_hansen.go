@@ -0,0 +1,133 @@
+package tests
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/DoOR-Team/timeseries_forecasting/arima/matrix"
+)
+
+// canovaHansenCriticalValues are the 5% critical values of Canova & Hansen
+// (1995), table 1, indexed by the number of tested frequencies less one,
+// which is m-1 when all seasonal frequencies are tested.
+var canovaHansenCriticalValues = []float64{
+	0.470, 0.749, 1.01, 1.24, 1.47, 1.68, 1.90, 2.11, 2.32, 2.54, 2.75, 2.96,
+}
+
+// CanovaHansen runs the Canova-Hansen test of stable seasonality at period
+// m, 2 <= m <= 13. The null hypothesis is a deterministic seasonal
+// pattern, so large statistics suggest seasonal differencing. y[t] is
+// regressed on a constant, y[t-1] and the seasonal harmonics, and the
+// statistic measures the drift of the partial sums of the residuals times
+// the harmonics. lags < 0 selects round(m*(n/100)^0.25) for the long-run
+// covariance. PValue is NaN.
+func CanovaHansen(data []float64, m, lags int) (*Result, error) {
+	if m < 2 || m > len(canovaHansenCriticalValues)+1 {
+		return nil, fmt.Errorf("%w: Canova-Hansen supports periods 2 to %d, got %d",
+			ErrInvalidArgument, len(canovaHansenCriticalValues)+1, m)
+	}
+	if len(data) < 2*m+2 {
+		return nil, fmt.Errorf("%w: Canova-Hansen needs at least %d points for m=%d, have %d",
+			ErrInsufficientData, 2*m+2, m, len(data))
+	}
+	n := len(data) - 1
+	if lags < 0 {
+		lags = int(math.Round(float64(m) * math.Pow(float64(n)/100, 0.25)))
+	}
+	if lags >= n {
+		return nil, fmt.Errorf("%w: Canova-Hansen lags=%d for n=%d", ErrInvalidArgument, lags, n)
+	}
+
+	harmonics := seasonalHarmonics(m, 1, n)
+	lagged := make([]float64, n)
+	for t := range lagged {
+		lagged[t] = data[t]
+	}
+	columns := append(deterministicColumns(Level, 1, n), lagged)
+	columns = append(columns, harmonics...)
+	_, _, residuals, err := ols(columns, data[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	// scores f[t] = e[t] * z[t] and their long-run covariance
+	k := len(harmonics)
+	scores := make([][]float64, k)
+	for j, z := range harmonics {
+		scores[j] = make([]float64, n)
+		for t := range z {
+			scores[j][t] = residuals[t] * z[t]
+		}
+	}
+	omega := make([][]float64, k)
+	for i := range omega {
+		omega[i] = make([]float64, k)
+		for j := range omega[i] {
+			omega[i][j] = neweyWestCovariance(scores[i], scores[j], lags)
+		}
+	}
+	covariance := matrix.NewInsightsMatrixWithData(omega, false)
+
+	statistic := 0.0
+	partialSums := matrix.NewInsightVector(k, 0)
+	for t := 0; t < n; t++ {
+		for j := range scores {
+			partialSums.Set(j, partialSums.Get(j)+scores[j][t])
+		}
+		solution := covariance.SolveSPDIntoVector(&partialSums, -1)
+		if solution == nil {
+			return nil, fmt.Errorf("%w: singular Canova-Hansen covariance", ErrSingularSystem)
+		}
+		statistic += partialSums.Dot(solution)
+	}
+	statistic /= float64(n) * float64(n)
+	return &Result{
+		Statistic:      statistic,
+		Lags:           lags,
+		CriticalValues: map[float64]float64{0.05: canovaHansenCriticalValues[k-1]},
+		PValue:         math.NaN(),
+	}, nil
+}
+
+// seasonalHarmonics returns the m-1 trigonometric seasonal regressors
+// cos(2 pi j t/m) and sin(2 pi j t/m), plus cos(pi t) for even m, for n
+// observations starting at time start.
+func seasonalHarmonics(m, start, n int) [][]float64 {
+	var harmonics [][]float64
+	for j := 1; 2*j < m; j++ {
+		cosine := make([]float64, n)
+		sine := make([]float64, n)
+		for t := range cosine {
+			angle := 2 * math.Pi * float64(j*(start+t)) / float64(m)
+			cosine[t] = math.Cos(angle)
+			sine[t] = math.Sin(angle)
+		}
+		harmonics = append(harmonics, cosine, sine)
+	}
+	if m%2 == 0 {
+		alternating := make([]float64, n)
+		for t := range alternating {
+			alternating[t] = 1 - 2*float64((start+t)%2)
+		}
+		harmonics = append(harmonics, alternating)
+	}
+	return harmonics
+}
+
+// neweyWestCovariance returns the Bartlett-weighted long-run covariance of
+// two mean-zero series.
+func neweyWestCovariance(x, y []float64, lags int) float64 {
+	n := float64(len(x))
+	covariance := 0.0
+	for t := range x {
+		covariance += x[t] * y[t]
+	}
+	for l := 1; l <= lags; l++ {
+		autocovariance := 0.0
+		for t := l; t < len(x); t++ {
+			autocovariance += x[t]*y[t-l] + x[t-l]*y[t]
+		}
+		covariance += (1 - float64(l)/float64(lags+1)) * autocovariance
+	}
+	return covariance / n
+}
@@ -0,0 +1,159 @@
+// Package tests implements the unit-root and stationarity tests used to
+// choose the differencing orders of an ARIMA model, and diagnostic tests
+// of its residuals.
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+var (
+	// ErrInsufficientData is returned when the series is too short for the test.
+	ErrInsufficientData = errors.New("insufficient data")
+	// ErrInvalidArgument is returned for unsupported test parameters.
+	ErrInvalidArgument = errors.New("invalid argument")
+	// ErrSingularSystem is returned when the test regression cannot be
+	// solved, e.g. for a constant series.
+	ErrSingularSystem = errors.New("singular linear system")
+)
+
+// Deterministic selects the deterministic terms of a test regression.
+type Deterministic int
+
+const (
+	// Level includes a constant.
+	Level Deterministic = iota
+	// Trend includes a constant and a linear time trend.
+	Trend
+)
+
+// Result holds the outcome of a statistical test.
+type Result struct {
+	Statistic      float64
+	Lags           int
+	CriticalValues map[float64]float64 // keyed by significance level, e.g. 0.05
+	// DF is the number of degrees of freedom of chi-square tests.
+	DF int
+	// PValue is approximate. KPSS p-values are interpolated in the table of
+	// critical values and therefore lie in [0.01, 0.1]. It is NaN for tests
+	// without a p-value approximation.
+	PValue float64
+}
+
+// kpssCriticalValues are the critical values from Kwiatkowski et al.
+// (1992), table 1.
+var kpssCriticalValues = map[Deterministic]map[float64]float64{
+	Level: {
+		0.10:  0.347,
+		0.05:  0.463,
+		0.025: 0.574,
+		0.01:  0.739,
+	},
+	Trend: {
+		0.10:  0.119,
+		0.05:  0.146,
+		0.025: 0.176,
+		0.01:  0.216,
+	},
+}
+
+// KPSS runs the Kwiatkowski-Phillips-Schmidt-Shin test for level or trend
+// stationarity. The null hypothesis is stationarity, so large statistics
+// suggest differencing. lags < 0 selects trunc(4*(n/100)^0.25).
+func KPSS(data []float64, deterministic Deterministic, lags int) (*Result, error) {
+	n := len(data)
+	if n < 3 {
+		return nil, fmt.Errorf("%w: KPSS needs at least 3 points, have %d", ErrInsufficientData, n)
+	}
+	criticalValues, ok := kpssCriticalValues[deterministic]
+	if !ok {
+		return nil, fmt.Errorf("%w: deterministic terms %d", ErrInvalidArgument, deterministic)
+	}
+	if lags < 0 {
+		lags = int(4 * math.Pow(float64(n)/100, 0.25))
+	}
+	if lags >= n {
+		return nil, fmt.Errorf("%w: KPSS lags=%d for n=%d", ErrInvalidArgument, lags, n)
+	}
+
+	_, _, residuals, err := ols(deterministicColumns(deterministic, 0, n), data)
+	if err != nil {
+		return nil, err
+	}
+
+	partialSum := 0.0
+	eta := 0.0
+	for _, e := range residuals {
+		partialSum += e
+		eta += partialSum * partialSum
+	}
+	eta /= float64(n) * float64(n)
+
+	statistic := 0.0
+	if longRunVariance := neweyWestVariance(residuals, lags); longRunVariance > 0 {
+		statistic = eta / longRunVariance
+	} // otherwise the series is constant: trivially stationary
+	return &Result{
+		Statistic:      statistic,
+		Lags:           lags,
+		CriticalValues: criticalValues,
+		PValue:         interpolatePValue(criticalValues, statistic),
+	}, nil
+}
+
+// interpolatePValue linearly interpolates the significance level of
+// statistic in a table of upper-tail critical values, clamping to the
+// range of the table.
+func interpolatePValue(criticalValues map[float64]float64, statistic float64) float64 {
+	levels := tableLevels(criticalValues)
+	// levels are decreasing, so critical values are increasing
+	for i, level := range levels {
+		critical := criticalValues[level]
+		if statistic <= critical {
+			if i == 0 {
+				return level
+			}
+			previous := criticalValues[levels[i-1]]
+			weight := (statistic - previous) / (critical - previous)
+			return levels[i-1] + weight*(level-levels[i-1])
+		}
+	}
+	return levels[len(levels)-1]
+}
+
+// interpolateCriticalValue is the inverse of interpolatePValue, with alpha
+// clamped to the range of the table.
+func interpolateCriticalValue(criticalValues map[float64]float64, alpha float64) float64 {
+	levels := tableLevels(criticalValues)
+	if alpha >= levels[0] {
+		return criticalValues[levels[0]]
+	}
+	for i := 1; i < len(levels); i++ {
+		if alpha >= levels[i] {
+			weight := (alpha - levels[i-1]) / (levels[i] - levels[i-1])
+			previous := criticalValues[levels[i-1]]
+			return previous + weight*(criticalValues[levels[i]]-previous)
+		}
+	}
+	return criticalValues[levels[len(levels)-1]]
+}
+
+// tableLevels returns the significance levels of a table in decreasing
+// order.
+func tableLevels(criticalValues map[float64]float64) []float64 {
+	levels := make([]float64, 0, len(criticalValues))
+	for level := range criticalValues {
+		levels = append(levels, level)
+	}
+	sort.Sort(sort.Reverse(sort.Float64Slice(levels)))
+	return levels
+}
+
+// neweyWestVariance returns the Bartlett-weighted long-run variance of
+// mean-zero residuals using the given number of lags.
+func neweyWestVariance(residuals []float64, lags int) float64 {
+	return neweyWestCovariance(residuals, residuals, lags)
+}
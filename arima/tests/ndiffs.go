@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+)
+
+// maxNDiffs bounds the differencing order recommended by NDiffs.
+const maxNDiffs = 2
+
+// Test selects the unit-root or stationarity test used by NDiffs.
+type Test int
+
+const (
+	KPSSTest Test = iota
+	ADFTest
+	PhillipsPerronTest
+)
+
+// NDiffs recommends the number of first differences, at most 2, needed to
+// make data stationary: it differences until the chosen test, run with a
+// constant, no longer points to a unit root at significance level alpha.
+// KPSS critical values are tabulated for alpha between 0.01 and 0.1, so
+// alpha is clamped to that range for KPSSTest.
+func NDiffs(data []float64, test Test, alpha float64) (int, error) {
+	if !(alpha > 0 && alpha < 1) {
+		return 0, fmt.Errorf("%w: significance level must be in (0, 1), got %v", ErrInvalidArgument, alpha)
+	}
+	if test != KPSSTest && test != ADFTest && test != PhillipsPerronTest {
+		return 0, fmt.Errorf("%w: unknown test %d", ErrInvalidArgument, test)
+	}
+	x := data
+	for d := 0; d < maxNDiffs; d++ {
+		if isConstant(x) {
+			return d, nil
+		}
+		needsDifference, err := hasUnitRoot(x, test, alpha)
+		if errors.Is(err, ErrInsufficientData) {
+			// too short to test any further
+			return d, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		if !needsDifference {
+			return d, nil
+		}
+		x = firstDifference(x)
+	}
+	return maxNDiffs, nil
+}
+
+// hasUnitRoot reports whether test points to a unit root in data at
+// significance level alpha.
+func hasUnitRoot(data []float64, test Test, alpha float64) (bool, error) {
+	switch test {
+	case ADFTest:
+		result, err := ADF(data, Level, -1)
+		if err != nil {
+			return false, err
+		}
+		return result.PValue >= alpha, nil
+	case PhillipsPerronTest:
+		result, err := PhillipsPerron(data, Level, -1)
+		if err != nil {
+			return false, err
+		}
+		return result.PValue >= alpha, nil
+	default:
+		result, err := KPSS(data, Level, -1)
+		if err != nil {
+			return false, err
+		}
+		return result.Statistic > interpolateCriticalValue(result.CriticalValues, alpha), nil
+	}
+}
+
+func isConstant(data []float64) bool {
+	for _, v := range data {
+		if v != data[0] {
+			return false
+		}
+	}
+	return true
+}
+
+func firstDifference(data []float64) []float64 {
+	if len(data) < 2 {
+		return nil
+	}
+	diff := make([]float64, len(data)-1)
+	for t := range diff {
+		diff[t] = data[t+1] - data[t]
+	}
+	return diff
+}
@@ -0,0 +1,56 @@
+package tests
+
+import "fmt"
+
+// seasonalStrengthThreshold is the seasonal strength above which
+// NSDiffs recommends a seasonal difference.
+const seasonalStrengthThreshold = 0.64
+
+// SeasonalTest selects the test used by NSDiffs.
+type SeasonalTest int
+
+const (
+	// SeasonalStrengthTest compares SeasonalStrength with 0.64.
+	SeasonalStrengthTest SeasonalTest = iota
+	OCSBTest
+	CanovaHansenTest
+)
+
+// NSDiffs recommends the number of seasonal differences at period m, 0 or
+// 1, needed to remove seasonal non-stationarity from data. The OCSB and
+// Canova-Hansen tests are run at the 5% level.
+func NSDiffs(data []float64, m int, test SeasonalTest) (int, error) {
+	if m < 2 {
+		return 0, fmt.Errorf("%w: seasonal period must be at least 2, got %d", ErrInvalidArgument, m)
+	}
+	if isConstant(data) {
+		return 0, nil
+	}
+	var needsDifference bool
+	switch test {
+	case SeasonalStrengthTest:
+		strength, err := SeasonalStrength(data, m)
+		if err != nil {
+			return 0, err
+		}
+		needsDifference = strength > seasonalStrengthThreshold
+	case OCSBTest:
+		result, err := OCSB(data, m)
+		if err != nil {
+			return 0, err
+		}
+		needsDifference = result.Statistic >= result.CriticalValues[0.05]
+	case CanovaHansenTest:
+		result, err := CanovaHansen(data, m, -1)
+		if err != nil {
+			return 0, err
+		}
+		needsDifference = result.Statistic > result.CriticalValues[0.05]
+	default:
+		return 0, fmt.Errorf("%w: unknown seasonal test %d", ErrInvalidArgument, test)
+	}
+	if needsDifference {
+		return 1, nil
+	}
+	return 0, nil
+}
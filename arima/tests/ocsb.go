@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"fmt"
+	"math"
+)
+
+// maxLagsOCSB bounds the lagged seasonal differences OCSB chooses from.
+const maxLagsOCSB = 3
+
+// OCSB runs the Osborn-Chui-Smith-Birchenhall test for a seasonal unit
+// root at period m. It regresses diff diff_m y[t] on diff_m y[t-1],
+// diff y[t-m] and up to 3 of its own lags, chosen by AIC, and reports the
+// t-statistic of diff y[t-m]. The null hypothesis is a seasonal unit root,
+// rejected when the statistic is below the 5% critical value, which is
+// interpolated in m as in R's forecast package. As there, the regression
+// has no seasonal dummies, so a strong deterministic seasonal pattern can
+// look like a seasonal unit root. PValue is NaN.
+func OCSB(data []float64, m int) (*Result, error) {
+	if m < 2 {
+		return nil, fmt.Errorf("%w: seasonal period must be at least 2, got %d", ErrInvalidArgument, m)
+	}
+	maxLags := maxLagsOCSB
+	if limit := (len(data)-m-1)/2 - 3; maxLags > limit {
+		maxLags = limit
+	}
+	if maxLags < 0 {
+		return nil, fmt.Errorf("%w: OCSB needs at least %d points for m=%d, have %d",
+			ErrInsufficientData, m+9, m, len(data))
+	}
+
+	bestLags, bestAIC := 0, math.Inf(1)
+	for lags := 0; lags <= maxLags; lags++ {
+		_, _, residuals, err := ols(ocsbRegression(data, m, lags, m+1+maxLags))
+		if err != nil {
+			return nil, err
+		}
+		squareSum := 0.0
+		for _, e := range residuals {
+			squareSum += e * e
+		}
+		n := float64(len(residuals))
+		if aic := n*math.Log(squareSum/n) + 2*float64(2+lags); aic < bestAIC {
+			bestLags, bestAIC = lags, aic
+		}
+	}
+
+	beta, stdErr, _, err := ols(ocsbRegression(data, m, bestLags, m+1+bestLags))
+	if err != nil {
+		return nil, err
+	}
+	logM := math.Log(float64(m)) - 0.7656451
+	critical := -0.2937411*math.Exp(-0.2850853*logM-0.05983644*logM*logM) - 1.652202
+	return &Result{
+		Statistic:      beta[1] / stdErr[1],
+		Lags:           bestLags,
+		CriticalValues: map[float64]float64{0.05: critical},
+		PValue:         math.NaN(),
+	}, nil
+}
+
+// ocsbRegression returns the regressors and response of the OCSB
+// regression for t from start.
+func ocsbRegression(data []float64, m, lags, start int) ([][]float64, []float64) {
+	seasonalDifference := func(t int) float64 {
+		return data[t] - data[t-1] - data[t-m] + data[t-m-1]
+	}
+	n := len(data) - start
+	response := make([]float64, n)
+	columns := make([][]float64, 2+lags)
+	for j := range columns {
+		columns[j] = make([]float64, n)
+	}
+	for t := start; t < len(data); t++ {
+		i := t - start
+		response[i] = seasonalDifference(t)
+		columns[0][i] = data[t-1] - data[t-1-m]
+		columns[1][i] = data[t-m] - data[t-m-1]
+		for l := 1; l <= lags; l++ {
+			columns[1+l][i] = seasonalDifference(t - l)
+		}
+	}
+	return columns, response
+}
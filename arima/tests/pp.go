@@ -0,0 +1,54 @@
+package tests
+
+import (
+	"fmt"
+	"math"
+)
+
+// PhillipsPerron runs the Phillips-Perron Z(t) test. Like ADF its null
+// hypothesis is a unit root, but serial correlation is handled by a
+// Newey-West correction instead of lagged differences. lags < 0 selects
+// trunc(4*(n/100)^0.25).
+func PhillipsPerron(data []float64, deterministic Deterministic, lags int) (*Result, error) {
+	if _, ok := mackinnonCriticalValues[deterministic]; !ok {
+		return nil, fmt.Errorf("%w: deterministic terms %d", ErrInvalidArgument, deterministic)
+	}
+	numRegressors := len(deterministicColumns(deterministic, 0, 0)) + 1
+	if len(data) < numRegressors+3 {
+		return nil, fmt.Errorf("%w: Phillips-Perron needs at least %d points, have %d",
+			ErrInsufficientData, numRegressors+3, len(data))
+	}
+	beta, stdErr, residuals, err := ols(adfRegression(data, deterministic, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+	n := len(residuals)
+	if lags < 0 {
+		lags = int(4 * math.Pow(float64(n)/100, 0.25))
+	}
+	if lags >= n {
+		return nil, fmt.Errorf("%w: Phillips-Perron lags=%d for n=%d", ErrInvalidArgument, lags, n)
+	}
+
+	squareSum := 0.0
+	for _, e := range residuals {
+		squareSum += e * e
+	}
+	gamma0 := squareSum / float64(n)
+	s := math.Sqrt(squareSum / float64(n-numRegressors))
+	longRunVariance := neweyWestVariance(residuals, lags)
+	if longRunVariance <= 0 {
+		return nil, fmt.Errorf("%w: residuals have zero long-run variance", ErrSingularSystem)
+	}
+
+	rho, se := beta[numRegressors-1], stdErr[numRegressors-1]
+	lambda := math.Sqrt(longRunVariance)
+	statistic := math.Sqrt(gamma0/longRunVariance)*rho/se -
+		(longRunVariance-gamma0)/(2*lambda)*float64(n)*se/s
+	return &Result{
+		Statistic:      statistic,
+		Lags:           lags,
+		CriticalValues: dickeyFullerCriticalValues(deterministic, n),
+		PValue:         dickeyFullerPValue(deterministic, statistic),
+	}, nil
+}
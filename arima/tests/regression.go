@@ -0,0 +1,66 @@
+package tests
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/DoOR-Team/timeseries_forecasting/arima/matrix"
+)
+
+// deterministicColumns returns the constant and, for Trend, the time index
+// of n observations starting at time start.
+func deterministicColumns(deterministic Deterministic, start, n int) [][]float64 {
+	ones := make([]float64, n)
+	for t := range ones {
+		ones[t] = 1
+	}
+	if deterministic != Trend {
+		return [][]float64{ones}
+	}
+	trend := make([]float64, n)
+	for t := range trend {
+		trend[t] = float64(start + t + 1)
+	}
+	return [][]float64{ones, trend}
+}
+
+// ols regresses y on the given regressor columns and returns the
+// coefficients, their standard errors and the residuals.
+func ols(columns [][]float64, y []float64) (beta, stdErr, residuals []float64, err error) {
+	n, k := len(y), len(columns)
+	if n <= k {
+		return nil, nil, nil, fmt.Errorf("%w: %d observations for %d regressors", ErrInsufficientData, n, k)
+	}
+	xtx := matrix.NewInsightsMatrixWithData(columns, false).ComputeAAT()
+	xty := matrix.NewInsightsMatrixWithData(columns, false).TimesVector(matrix.NewInsightVectorWithData(y, false))
+	solution := xtx.SolveSPDIntoVector(xty, -1)
+	if solution == nil {
+		return nil, nil, nil, fmt.Errorf("%w: test regressors are collinear", ErrSingularSystem)
+	}
+	beta = solution.DeepCopy()
+
+	residuals = append([]float64(nil), y...)
+	squareSum := 0.0
+	for t := range residuals {
+		for j, x := range columns {
+			residuals[t] -= beta[j] * x[t]
+		}
+		squareSum += residuals[t] * residuals[t]
+	}
+	s2 := squareSum / float64(n-k)
+
+	stdErr = make([]float64, k)
+	for j := range stdErr {
+		unit := matrix.NewInsightVector(k, 0)
+		unit.Set(j, 1)
+		stdErr[j] = math.Sqrt(s2 * xtx.SolveSPDIntoVector(&unit, -1).Get(j))
+	}
+	for _, values := range [][]float64{beta, stdErr} {
+		for _, v := range values {
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				return nil, nil, nil, fmt.Errorf("%w: test regression is ill-conditioned", ErrSingularSystem)
+			}
+		}
+	}
+	return beta, stdErr, residuals, nil
+}
@@ -0,0 +1,166 @@
+package tests
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/DoOR-Team/timeseries_forecasting/arima/utils"
+)
+
+// LjungBox runs the Ljung-Box portmanteau test that the first lags
+// autocorrelations of residuals are zero. fitdf is the number of ARMA
+// coefficients estimated to obtain the residuals; the statistic is
+// compared with a chi-square distribution on lags - fitdf degrees of
+// freedom.
+func LjungBox(residuals []float64, lags, fitdf int) (*Result, error) {
+	return portmanteau(residuals, lags, fitdf, true)
+}
+
+// BoxPierce runs the Box-Pierce portmanteau test, the large-sample version
+// of LjungBox.
+func BoxPierce(residuals []float64, lags, fitdf int) (*Result, error) {
+	return portmanteau(residuals, lags, fitdf, false)
+}
+
+func portmanteau(residuals []float64, lags, fitdf int, ljungBox bool) (*Result, error) {
+	n := len(residuals)
+	if lags < 1 || fitdf < 0 || lags <= fitdf {
+		return nil, fmt.Errorf("%w: portmanteau tests need 0 <= fitdf < lags, got lags=%d, fitdf=%d",
+			ErrInvalidArgument, lags, fitdf)
+	}
+	if n <= lags {
+		return nil, fmt.Errorf("%w: portmanteau test with %d lags needs more than %d points, have %d",
+			ErrInsufficientData, lags, lags, n)
+	}
+	acf, err := autocorrelations(residuals, lags)
+	if err != nil {
+		return nil, err
+	}
+	statistic := 0.0
+	for k := 1; k <= lags; k++ {
+		if ljungBox {
+			statistic += acf[k] * acf[k] / float64(n-k)
+		} else {
+			statistic += acf[k] * acf[k]
+		}
+	}
+	statistic *= float64(n)
+	if ljungBox {
+		statistic *= float64(n + 2)
+	}
+	df := lags - fitdf
+	return &Result{
+		Statistic: statistic,
+		Lags:      lags,
+		DF:        df,
+		PValue:    1 - utils.ChiSquareCDF(statistic, float64(df)),
+	}, nil
+}
+
+// JarqueBera runs the Jarque-Bera test that residuals are normally
+// distributed, based on their skewness and excess kurtosis. The statistic
+// is compared with a chi-square distribution on 2 degrees of freedom.
+func JarqueBera(residuals []float64) (*Result, error) {
+	n := len(residuals)
+	if n < 3 {
+		return nil, fmt.Errorf("%w: Jarque-Bera needs at least 3 points, have %d", ErrInsufficientData, n)
+	}
+	mean := 0.0
+	for _, v := range residuals {
+		mean += v
+	}
+	mean /= float64(n)
+	var m2, m3, m4 float64
+	for _, v := range residuals {
+		d := v - mean
+		m2 += d * d
+		m3 += d * d * d
+		m4 += d * d * d * d
+	}
+	m2 /= float64(n)
+	m3 /= float64(n)
+	m4 /= float64(n)
+	if m2 == 0 {
+		return nil, fmt.Errorf("%w: residuals are constant", ErrSingularSystem)
+	}
+	skewness := m3 / math.Pow(m2, 1.5)
+	excessKurtosis := m4/(m2*m2) - 3
+	statistic := float64(n) / 6 * (skewness*skewness + excessKurtosis*excessKurtosis/4)
+	return &Result{
+		Statistic: statistic,
+		DF:        2,
+		PValue:    1 - utils.ChiSquareCDF(statistic, 2),
+	}, nil
+}
+
+// ARCHLM runs Engle's Lagrange multiplier test for autoregressive
+// conditional heteroskedasticity: the squared residuals are regressed on
+// a constant and their first lags values, and n R^2 is compared with a
+// chi-square distribution on lags degrees of freedom.
+func ARCHLM(residuals []float64, lags int) (*Result, error) {
+	if lags < 1 {
+		return nil, fmt.Errorf("%w: ARCH-LM lags must be positive, got %d", ErrInvalidArgument, lags)
+	}
+	n := len(residuals) - lags
+	if n <= lags+1 {
+		return nil, fmt.Errorf("%w: ARCH-LM with %d lags needs more than %d points, have %d",
+			ErrInsufficientData, lags, 2*lags+1, len(residuals))
+	}
+	squared := make([]float64, len(residuals))
+	for t, e := range residuals {
+		squared[t] = e * e
+	}
+	columns := deterministicColumns(Level, 0, n)
+	for l := 1; l <= lags; l++ {
+		columns = append(columns, squared[lags-l:len(squared)-l])
+	}
+	response := squared[lags:]
+	_, _, auxiliary, err := ols(columns, response)
+	if err != nil {
+		return nil, err
+	}
+	mean := 0.0
+	for _, v := range response {
+		mean += v
+	}
+	mean /= float64(n)
+	var residualSum, totalSum float64
+	for t, v := range response {
+		residualSum += auxiliary[t] * auxiliary[t]
+		totalSum += (v - mean) * (v - mean)
+	}
+	if totalSum == 0 {
+		return nil, fmt.Errorf("%w: squared residuals are constant", ErrSingularSystem)
+	}
+	statistic := float64(n) * (1 - residualSum/totalSum)
+	return &Result{
+		Statistic: statistic,
+		Lags:      lags,
+		DF:        lags,
+		PValue:    1 - utils.ChiSquareCDF(statistic, float64(lags)),
+	}, nil
+}
+
+// autocorrelations returns the sample autocorrelations of data about its
+// mean at lags 0 to maxLag.
+func autocorrelations(data []float64, maxLag int) ([]float64, error) {
+	n := len(data)
+	mean := 0.0
+	for _, v := range data {
+		mean += v
+	}
+	mean /= float64(n)
+	acf := make([]float64, maxLag+1)
+	for k := range acf {
+		for t := k; t < n; t++ {
+			acf[k] += (data[t] - mean) * (data[t-k] - mean)
+		}
+	}
+	if acf[0] == 0 {
+		return nil, fmt.Errorf("%w: residuals are constant", ErrSingularSystem)
+	}
+	for k := len(acf) - 1; k >= 0; k-- {
+		acf[k] /= acf[0]
+	}
+	return acf, nil
+}
@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"fmt"
+	"math"
+)
+
+// SeasonalStrength measures how much of the variation in data is explained
+// by a period-m seasonal component, following Wang, Smith & Hyndman (2006):
+// max(0, 1 - Var(remainder)/Var(seasonal+remainder)) of a classical
+// additive decomposition. Values above 0.64 usually warrant a seasonal
+// difference.
+func SeasonalStrength(data []float64, m int) (float64, error) {
+	if m < 2 {
+		return 0, fmt.Errorf("%w: seasonal period must be at least 2, got %d", ErrInvalidArgument, m)
+	}
+	n := len(data)
+	if n < 2*m+1 {
+		return 0, fmt.Errorf("%w: need at least two seasons plus one point, have %d for m=%d",
+			ErrInsufficientData, n, m)
+	}
+
+	trend := centredMovingAverage(data, m)
+
+	// seasonal indices from the average detrended value per season position
+	sums := make([]float64, m)
+	counts := make([]int, m)
+	for t := 0; t < n; t++ {
+		if !math.IsNaN(trend[t]) {
+			sums[t%m] += data[t] - trend[t]
+			counts[t%m]++
+		}
+	}
+	seasonal := make([]float64, m)
+	seasonalMean := 0.0
+	for i := range seasonal {
+		seasonal[i] = sums[i] / float64(counts[i])
+		seasonalMean += seasonal[i]
+	}
+	seasonalMean /= float64(m)
+
+	detrended := make([]float64, 0, n)
+	remainder := make([]float64, 0, n)
+	for t := 0; t < n; t++ {
+		if math.IsNaN(trend[t]) {
+			continue
+		}
+		s := seasonal[t%m] - seasonalMean
+		detrended = append(detrended, data[t]-trend[t])
+		remainder = append(remainder, data[t]-trend[t]-s)
+	}
+
+	varianceDetrended := variance(detrended)
+	if varianceDetrended == 0 {
+		return 0, nil
+	}
+	strength := 1 - variance(remainder)/varianceDetrended
+	if strength < 0 {
+		strength = 0
+	}
+	return strength, nil
+}
+
+// centredMovingAverage returns the centred moving average of order m, with
+// NaN where the window does not fit.
+func centredMovingAverage(data []float64, m int) []float64 {
+	n := len(data)
+	trend := make([]float64, n)
+	half := m / 2
+	for t := 0; t < n; t++ {
+		if t-half < 0 || t+half >= n {
+			trend[t] = math.NaN()
+			continue
+		}
+		sum := 0.0
+		if m%2 == 1 {
+			for k := t - half; k <= t+half; k++ {
+				sum += data[k]
+			}
+			trend[t] = sum / float64(m)
+		} else {
+			// 2xm moving average for even periods
+			sum += 0.5 * data[t-half]
+			sum += 0.5 * data[t+half]
+			for k := t - half + 1; k < t+half; k++ {
+				sum += data[k]
+			}
+			trend[t] = sum / float64(m)
+		}
+	}
+	return trend
+}
+
+func variance(data []float64) float64 {
+	n := len(data)
+	if n < 2 {
+		return 0
+	}
+	mean := 0.0
+	for _, v := range data {
+		mean += v
+	}
+	mean /= float64(n)
+	sum := 0.0
+	for _, v := range data {
+		sum += (v - mean) * (v - mean)
+	}
+	return sum / float64(n-1)
+}
@@ -0,0 +1,270 @@
+package tests
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/DoOR-Team/timeseries_forecasting/arima/utils"
+)
+
+func whiteNoise(n int, seed int64) []float64 {
+	rng := rand.New(rand.NewSource(seed))
+	data := make([]float64, n)
+	for i := range data {
+		data[i] = rng.NormFloat64()
+	}
+	return data
+}
+
+func randomWalk(n int, seed int64) []float64 {
+	data := whiteNoise(n, seed)
+	for i := 1; i < n; i++ {
+		data[i] += data[i-1]
+	}
+	return data
+}
+
+func TestKPSS(t *testing.T) {
+	stationary, err := KPSS(whiteNoise(200, 1), Level, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stationary.Statistic > stationary.CriticalValues[0.05] || stationary.PValue < 0.05 {
+		t.Fatalf("white noise rejected as non-stationary: %v (p=%v)", stationary.Statistic, stationary.PValue)
+	}
+	walk, err := KPSS(randomWalk(200, 1), Level, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if walk.Statistic < walk.CriticalValues[0.05] || walk.PValue > 0.05 {
+		t.Fatalf("random walk accepted as stationary: %v (p=%v)", walk.Statistic, walk.PValue)
+	}
+
+	// a linear trend is stationary around the trend but not the level
+	trending := whiteNoise(200, 3)
+	for i := range trending {
+		trending[i] += 0.05 * float64(i)
+	}
+	level, err := KPSS(trending, Level, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trend, err := KPSS(trending, Trend, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if level.PValue > 0.01 || trend.PValue < 0.1 {
+		t.Fatalf("expected p-values 0.01 for level and 0.1 for trend, got %v and %v", level.PValue, trend.PValue)
+	}
+
+	if p := interpolatePValue(kpssCriticalValues[Level], 0.5185); math.Abs(p-0.0375) > 1e-9 {
+		t.Fatalf("expected interpolated p-value 0.0375, got %v", p)
+	}
+	if c := interpolateCriticalValue(kpssCriticalValues[Level], 0.0375); math.Abs(c-0.5185) > 1e-9 {
+		t.Fatalf("expected interpolated critical value 0.5185, got %v", c)
+	}
+}
+
+func TestDickeyFuller(t *testing.T) {
+	for _, deterministic := range []Deterministic{Level, Trend} {
+		for name, test := range map[string]func([]float64, Deterministic, int) (*Result, error){
+			"ADF": ADF, "PP": PhillipsPerron,
+		} {
+			stationary, err := test(whiteNoise(200, 4), deterministic, -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stationary.Statistic > stationary.CriticalValues[0.01] || stationary.PValue > 0.01 {
+				t.Fatalf("%s(%d): white noise not rejected as a unit root: %v (p=%v)",
+					name, deterministic, stationary.Statistic, stationary.PValue)
+			}
+			walk, err := test(randomWalk(200, 4), deterministic, -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if walk.Statistic < walk.CriticalValues[0.10] || walk.PValue < 0.1 {
+				t.Fatalf("%s(%d): random walk rejected as a unit root: %v (p=%v)",
+					name, deterministic, walk.Statistic, walk.PValue)
+			}
+		}
+	}
+
+	// MacKinnon (1994) p-values at the asymptotic critical values
+	if p := dickeyFullerPValue(Level, -2.86154); math.Abs(p-0.05) > 0.005 {
+		t.Fatalf("expected p-value near 0.05, got %v", p)
+	}
+	if p := dickeyFullerPValue(Trend, -3.95877); math.Abs(p-0.01) > 0.002 {
+		t.Fatalf("expected p-value near 0.01, got %v", p)
+	}
+
+	if _, err := ADF(whiteNoise(3, 5), Level, -1); !errors.Is(err, ErrInsufficientData) {
+		t.Fatalf("expected ErrInsufficientData, got %v", err)
+	}
+}
+
+func TestNDiffs(t *testing.T) {
+	integratedTwice := randomWalk(300, 6)
+	for i := 1; i < len(integratedTwice); i++ {
+		integratedTwice[i] += integratedTwice[i-1]
+	}
+	for _, test := range []Test{KPSSTest, ADFTest, PhillipsPerronTest} {
+		for want, data := range [][]float64{whiteNoise(300, 6), randomWalk(300, 6), integratedTwice} {
+			d, err := NDiffs(data, test, 0.05)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d != want {
+				t.Fatalf("test %d: expected %d differences, got %d", test, want, d)
+			}
+		}
+	}
+	if _, err := NDiffs(whiteNoise(50, 7), ADFTest, 1.5); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("expected ErrInvalidArgument, got %v", err)
+	}
+}
+
+func TestSeasonalStrength(t *testing.T) {
+	noise := whiteNoise(96, 2)
+	seasonal := make([]float64, len(noise))
+	for i := range seasonal {
+		seasonal[i] = 5*math.Sin(2*math.Pi*float64(i)/12) + 0.5*noise[i]
+	}
+	strong, err := SeasonalStrength(seasonal, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	weak, err := SeasonalStrength(noise, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strong < 0.64 || weak > 0.64 {
+		t.Fatalf("unexpected seasonal strengths: seasonal=%v noise=%v", strong, weak)
+	}
+}
+
+func TestSeasonalUnitRoots(t *testing.T) {
+	const m = 4
+	// seasonal random walk y[t] = y[t-4] + e[t]
+	noise := whiteNoise(200, 8)
+	seasonalWalk := make([]float64, len(noise))
+	stable := make([]float64, len(noise))
+	for i := range noise {
+		seasonalWalk[i] = noise[i]
+		if i >= m {
+			seasonalWalk[i] += seasonalWalk[i-m]
+		}
+		stable[i] = 3*math.Sin(2*math.Pi*float64(i)/m) + noise[i]
+	}
+
+	cases := []struct {
+		test SeasonalTest
+		data []float64
+		want int
+	}{
+		// the heuristic only measures how regular the seasonal pattern is
+		{SeasonalStrengthTest, stable, 1},
+		{SeasonalStrengthTest, whiteNoise(200, 10), 0},
+		{OCSBTest, seasonalWalk, 1},
+		{OCSBTest, whiteNoise(200, 10), 0},
+		{CanovaHansenTest, seasonalWalk, 1},
+		{CanovaHansenTest, whiteNoise(200, 10), 0},
+		// deterministic seasonality needs dummies, not differencing
+		{CanovaHansenTest, stable, 0},
+	}
+	for i, c := range cases {
+		D, err := NSDiffs(c.data, m, c.test)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if D != c.want {
+			t.Fatalf("case %d: test %d recommends D=%d, expected %d", i, c.test, D, c.want)
+		}
+	}
+
+	if _, err := CanovaHansen(seasonalWalk, 24, -1); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("expected ErrInvalidArgument for m=24, got %v", err)
+	}
+	if _, err := OCSB(whiteNoise(10, 10), m); !errors.Is(err, ErrInsufficientData) {
+		t.Fatalf("expected ErrInsufficientData, got %v", err)
+	}
+}
+
+func TestResidualDiagnostics(t *testing.T) {
+	for _, c := range []struct{ x, df, want float64 }{
+		{3.841459, 1, 0.95}, {18.307038, 10, 0.95}, {0.554300, 5, 0.01}, {4, 2, 1 - math.Exp(-2)},
+	} {
+		if got := utils.ChiSquareCDF(c.x, c.df); math.Abs(got-c.want) > 1e-6 {
+			t.Fatalf("ChiSquareCDF(%v, %v) = %v, expected %v", c.x, c.df, got, c.want)
+		}
+	}
+
+	noise := whiteNoise(500, 11)
+	ar := make([]float64, len(noise))
+	for i := range ar {
+		ar[i] = noise[i]
+		if i > 0 {
+			ar[i] += 0.5 * ar[i-1]
+		}
+	}
+	for name, test := range map[string]func([]float64, int, int) (*Result, error){
+		"Ljung-Box": LjungBox, "Box-Pierce": BoxPierce,
+	} {
+		accepted, err := test(noise, 10, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if accepted.DF != 8 || accepted.PValue < 0.05 {
+			t.Fatalf("%s: white noise rejected with p=%v on %d df", name, accepted.PValue, accepted.DF)
+		}
+		rejected, err := test(ar, 10, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rejected.PValue > 1e-6 {
+			t.Fatalf("%s: AR(1) accepted with p=%v", name, rejected.PValue)
+		}
+	}
+	if _, err := LjungBox(noise, 2, 2); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("expected ErrInvalidArgument when fitdf >= lags, got %v", err)
+	}
+
+	normal, err := JarqueBera(noise)
+	if err != nil {
+		t.Fatal(err)
+	}
+	skewed := make([]float64, len(noise))
+	for i, v := range noise {
+		skewed[i] = math.Exp(v)
+	}
+	lognormal, err := JarqueBera(skewed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if normal.PValue < 0.05 || lognormal.PValue > 1e-6 {
+		t.Fatalf("unexpected Jarque-Bera p-values: normal %v, lognormal %v", normal.PValue, lognormal.PValue)
+	}
+
+	// ARCH(1): the variance depends on the previous shock
+	arch := make([]float64, len(noise))
+	for i := range arch {
+		variance := 1.0
+		if i > 0 {
+			variance += 0.7 * arch[i-1] * arch[i-1]
+		}
+		arch[i] = math.Sqrt(variance) * noise[i]
+	}
+	homoskedastic, err := ARCHLM(noise, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	heteroskedastic, err := ARCHLM(arch, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if homoskedastic.PValue < 0.05 || heteroskedastic.PValue > 1e-6 {
+		t.Fatalf("unexpected ARCH-LM p-values: white noise %v, ARCH(1) %v",
+			homoskedastic.PValue, heteroskedastic.PValue)
+	}
+}
@@ -1,14 +1,15 @@
 package arima
 
 import (
+	"fmt"
 	"math"
 
 	"github.com/DoOR-Team/timeseries_forecasting/arima/matrix"
+	"github.com/DoOR-Team/timeseries_forecasting/arima/utils"
 )
 
-const testSetPercentage = 0.15
 const maxConditionNumber float64 = 100
-const confidence_constant_95pct = 1.959963984540054
+const defaultConfidenceLevel = 0.95
 
 func initToeplitz(input []float64) *matrix.InsightsMatrix {
 	length := len(input)
@@ -31,6 +32,9 @@ func initToeplitz(input []float64) *matrix.InsightsMatrix {
 	return matrix.NewInsightsMatrixWithData(toeplitz, false)
 }
 
+// ARMAtoMA returns the first lag_max weights psi_0 = 1, psi_1, ... of the
+// MA(infinity) representation of the ARMA process with AR coefficients ar
+// and MA coefficients ma, both indexed by lag-1.
 func ARMAtoMA(ar []float64, ma []float64, lag_max int) []float64 {
 	p := len(ar)
 	q := len(ma)
@@ -42,9 +46,10 @@ func ARMAtoMA(ar []float64, ma []float64, lag_max int) []float64 {
 			tmp = ma[i]
 		}
 		for j := 0; j < int(math.Min(float64(i+1), float64(p))); j++ {
-			tmp = ar[j]
 			if i-j-1 >= 0 {
-				tmp = tmp * psi[i-j-1]
+				tmp += ar[j] * psi[i-j-1]
+			} else {
+				tmp += ar[j]
 			}
 		}
 		psi[i] = tmp
@@ -67,3 +72,97 @@ func getCumulativeSumOfCoeff(coeffs []float64) []float64 {
 	}
 	return cumulativeSquaredCoeffSumVector
 }
+
+// countMissing returns the number of NaN values in data, which mark
+// missing observations, and whether every other value is finite.
+func countMissing(data []float64) (missing int, ok bool) {
+	for _, v := range data {
+		if math.IsNaN(v) {
+			missing++
+		} else if math.IsInf(v, 0) {
+			return missing, false
+		}
+	}
+	return missing, true
+}
+
+// completeCases returns the columns and response restricted to the
+// observations where none of them is missing.
+func completeCases(columns [][]float64, response []float64) ([][]float64, []float64) {
+	complete := make([]int, 0, len(response))
+	for t, y := range response {
+		ok := !math.IsNaN(y)
+		for _, x := range columns {
+			ok = ok && !math.IsNaN(x[t])
+		}
+		if ok {
+			complete = append(complete, t)
+		}
+	}
+	if len(complete) == len(response) {
+		return columns, response
+	}
+	filteredColumns := make([][]float64, len(columns))
+	for j, x := range columns {
+		filteredColumns[j] = make([]float64, len(complete))
+		for i, t := range complete {
+			filteredColumns[j][i] = x[t]
+		}
+	}
+	filteredResponse := make([]float64, len(complete))
+	for i, t := range complete {
+		filteredResponse[i] = response[t]
+	}
+	return filteredColumns, filteredResponse
+}
+
+func isFinite(data []float64) bool {
+	for _, v := range data {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// validateLevels checks that every confidence level lies strictly between
+// 0 and 1 and is not repeated.
+func validateLevels(levels []float64) error {
+	for i, level := range levels {
+		if !(level > 0 && level < 1) {
+			return fmt.Errorf("confidence level must be in (0, 1), got %v", level)
+		}
+		for _, other := range levels[:i] {
+			if other == level {
+				return fmt.Errorf("duplicate confidence level %v", level)
+			}
+		}
+	}
+	return nil
+}
+
+// levelToConstant converts a two-sided confidence level such as 0.95 into
+// the matching standard normal quantile.
+func levelToConstant(level float64) float64 {
+	return utils.NormalQuantile(0.5 + level/2)
+}
+
+// isStationaryAR reports whether the AR polynomial 1 - ar[0] z - ... has all
+// its roots outside the unit circle, using the step-down (Schur-Cohn)
+// recursion: every reflection coefficient must lie inside (-1, 1).
+func isStationaryAR(ar []float64) bool {
+	a := append([]float64(nil), ar...)
+	for k := len(a); k > 0; k-- {
+		reflection := a[k-1]
+		if math.IsNaN(reflection) || math.Abs(reflection) >= 1 {
+			return false
+		}
+		denominator := 1 - reflection*reflection
+		next := make([]float64, k-1)
+		for j := 0; j < k-1; j++ {
+			next[j] = (a[j] + reflection*a[k-2-j]) / denominator
+		}
+		a = next
+	}
+	return true
+}
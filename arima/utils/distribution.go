@@ -0,0 +1,130 @@
+package utils
+
+import "math"
+
+// NormalCDF returns the standard normal cumulative distribution function at x.
+func NormalCDF(x float64) float64 {
+	return 0.5 * math.Erfc(-x/math.Sqrt2)
+}
+
+// NormalQuantile returns the standard normal quantile for probability p,
+// using Wichura's algorithm AS241 (accurate to about 1e-16).
+// It returns -Inf for p == 0, +Inf for p == 1 and NaN outside [0, 1].
+func NormalQuantile(p float64) float64 {
+	if math.IsNaN(p) || p < 0 || p > 1 {
+		return math.NaN()
+	}
+	if p == 0 {
+		return math.Inf(-1)
+	}
+	if p == 1 {
+		return math.Inf(1)
+	}
+	q := p - 0.5
+	if math.Abs(q) <= 0.425 {
+		r := 0.180625 - q*q
+		return q * (((((((2509.0809287301226727*r+
+			33430.575583588128105)*r+67265.770927008700853)*r+
+			45921.953931549871457)*r+13731.693765509461125)*r+
+			1971.5909503065514427)*r+133.14166789178437745)*r +
+			3.387132872796366608) /
+			(((((((5226.495278852545925*r+
+				28729.085735721942674)*r+39307.89580009271061)*r+
+				21213.794301586595867)*r+5394.1960214247511077)*r+
+				687.1870074920579083)*r+42.313330701600911252)*r + 1)
+	}
+	r := p
+	if q > 0 {
+		r = 1 - p
+	}
+	r = math.Sqrt(-math.Log(r))
+	var val float64
+	if r <= 5 {
+		r -= 1.6
+		val = (((((((7.7454501427834140764e-4*r+
+			0.0227238449892691845833)*r+0.24178072517745061177)*r+
+			1.27045825245236838258)*r+3.64784832476320460504)*r+
+			5.7694972214606914055)*r+4.6303378461565452959)*r +
+			1.42343711074968357734) /
+			(((((((1.05075007164441684324e-9*r+
+				5.475938084995344946e-4)*r+0.0151986665636164571966)*r+
+				0.14810397642748007459)*r+0.68976733498510000455)*r+
+				1.6763848301838038494)*r+2.05319162663775882187)*r + 1)
+	} else {
+		r -= 5
+		val = (((((((2.01033439929228813265e-7*r+
+			2.71155556874348757815e-5)*r+0.0012426609473880784386)*r+
+			0.026532189526576123093)*r+0.29656057182850489123)*r+
+			1.7848265399172913358)*r+5.4637849111641143699)*r +
+			6.6579046435011037772) /
+			(((((((2.04426310338993978564e-15*r+
+				1.4215117583164458887e-7)*r+1.8463183175100546818e-5)*r+
+				7.868691311456132591e-4)*r+0.0148753612908506148525)*r+
+				0.13692988092273580531)*r+0.59983220655588793769)*r + 1)
+	}
+	if q < 0 {
+		val = -val
+	}
+	return val
+}
+
+// ChiSquareCDF returns the chi-square cumulative distribution function
+// with df degrees of freedom at x.
+func ChiSquareCDF(x, df float64) float64 {
+	if math.IsNaN(x) || math.IsNaN(df) || df <= 0 {
+		return math.NaN()
+	}
+	if x <= 0 {
+		return 0
+	}
+	return regularizedGammaP(df/2, x/2)
+}
+
+// regularizedGammaP returns the regularized lower incomplete gamma
+// function P(a, x), by its series for x < a+1 and by the continued
+// fraction of Q = 1 - P otherwise.
+func regularizedGammaP(a, x float64) float64 {
+	const (
+		eps           = 1e-15
+		tiny          = 1e-300
+		maxIterations = 1000
+	)
+	logGammaA, _ := math.Lgamma(a)
+	prefactor := math.Exp(-x + a*math.Log(x) - logGammaA)
+	if x < a+1 {
+		term := 1 / a
+		sum := term
+		for n := 1; n < maxIterations; n++ {
+			term *= x / (a + float64(n))
+			sum += term
+			if math.Abs(term) < math.Abs(sum)*eps {
+				break
+			}
+		}
+		return sum * prefactor
+	}
+	// modified Lentz evaluation of the continued fraction
+	b := x + 1 - a
+	c := 1 / tiny
+	d := 1 / b
+	h := d
+	for n := 1; n < maxIterations; n++ {
+		an := -float64(n) * (float64(n) - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = b + an/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < eps {
+			break
+		}
+	}
+	return 1 - prefactor*h
+}
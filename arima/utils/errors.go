@@ -0,0 +1,10 @@
+package utils
+
+import "errors"
+
+// Sentinel errors returned by the differencing helpers. They are shared
+// with the arima package so callers can match them with errors.Is.
+var (
+	ErrInsufficientData = errors.New("insufficient data")
+	ErrInvalidArgument  = errors.New("invalid argument")
+)
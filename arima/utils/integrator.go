@@ -2,16 +2,16 @@ package utils
 
 import "fmt"
 
-func Differentiate(src, dst, initial []float64, d int) {
+func Differentiate(src, dst, initial []float64, d int) error {
 	if initial == nil || len(initial) != d || d <= 0 {
-		panic(fmt.Sprintf("invalid initial size=%d, d=%d", len(initial), d))
+		return fmt.Errorf("%w: invalid initial size=%d, d=%d", ErrInvalidArgument, len(initial), d)
 	}
 	if src == nil || len(src) <= d {
-		panic(fmt.Sprintf("insufficient source size=%d, d=%d", len(src), d))
+		return fmt.Errorf("%w: insufficient source size=%d, d=%d", ErrInsufficientData, len(src), d)
 	}
 	if dst == nil || len(dst) != len(src)-d {
-		panic(fmt.Sprintf(
-			"invalid destination size=%d, src=%d, d=%d", len(dst), len(src), d))
+		return fmt.Errorf("%w: invalid destination size=%d, src=%d, d=%d",
+			ErrInvalidArgument, len(dst), len(src), d)
 	}
 
 	// copy over initial conditions
@@ -24,19 +24,19 @@ func Differentiate(src, dst, initial []float64, d int) {
 		dst[k] = src[j] - src[k]
 		k++
 	}
-
+	return nil
 }
 
-func Integrate(src, dst, initial []float64, d int) {
+func Integrate(src, dst, initial []float64, d int) error {
 	if initial == nil || len(initial) != d || d <= 0 {
-		panic(fmt.Sprintf("invalid initial size=%d, d=%d", len(initial), d))
+		return fmt.Errorf("%w: invalid initial size=%d, d=%d", ErrInvalidArgument, len(initial), d)
 	}
 	if src == nil || len(src) <= d {
-		panic(fmt.Sprintf("insufficient source size=%d, d=%d", len(src), d))
+		return fmt.Errorf("%w: insufficient source size=%d, d=%d", ErrInsufficientData, len(src), d)
 	}
 	if dst == nil || len(src) != len(dst)-d {
-		panic(fmt.Sprintf(
-			"invalid destination size=%d, src=%d, d=%d", len(dst), len(src), d))
+		return fmt.Errorf("%w: invalid destination size=%d, src=%d, d=%d",
+			ErrInvalidArgument, len(dst), len(src), d)
 	}
 
 	// copy over initial conditions
@@ -49,6 +49,7 @@ func Integrate(src, dst, initial []float64, d int) {
 		dst[j] = dst[k] + src[k]
 		k++
 	}
+	return nil
 }
 
 func Shift(inputData []float64, shiftAmount float64) {
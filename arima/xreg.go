@@ -0,0 +1,253 @@
+package arima
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/DoOR-Team/timeseries_forecasting/arima/matrix"
+)
+
+// regression holds the regressors of a regression with ARIMA errors,
+// y_t = sum_j beta_j x_{j,t} + eta_t with eta_t ~ ARIMA. Besides the
+// exogenous regressors it carries the constant terms: a drift, which is
+// the time index t = 1, ..., n, and a mean, which is a column of ones.
+// Response and regressors are differenced with the same operators so the
+// ARMA part is estimated on a stationary series.
+type regression struct {
+	xreg      [][]float64 // original exogenous regressors, one slice per regressor
+	drift     bool
+	intercept bool
+	beta      []float64 // exogenous regressors, then drift, then mean
+
+	response   []float64   // differenced response
+	regressors [][]float64 // differenced design columns
+}
+
+func newRegression(data []float64, xreg [][]float64, spec Config, intercept, drift bool) (*regression, error) {
+	if xreg != nil {
+		if err := validateRegressors(xreg, len(data)); err != nil {
+			return nil, err
+		}
+	}
+	r := &regression{
+		xreg:      make([][]float64, len(xreg)),
+		drift:     drift,
+		intercept: intercept,
+	}
+	for j, x := range xreg {
+		r.xreg[j] = append([]float64(nil), x...)
+	}
+	var err error
+	if r.response, err = differenceWith(spec, data); err != nil {
+		return nil, err
+	}
+	design := r.design(r.xreg, 0, len(data))
+	r.regressors = make([][]float64, len(design))
+	for j, x := range design {
+		if r.regressors[j], err = differenceWith(spec, x); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// hasExogenous reports whether the model needs future regressor values.
+func (r *regression) hasExogenous() bool {
+	return r != nil && len(r.xreg) > 0
+}
+
+// design returns the regressor columns for h observations starting after
+// offset observations: the exogenous regressors followed by the drift and
+// mean columns.
+func (r *regression) design(xreg [][]float64, offset, h int) [][]float64 {
+	columns := append([][]float64(nil), xreg...)
+	if r.drift {
+		trend := make([]float64, h)
+		for t := range trend {
+			trend[t] = float64(offset + t + 1)
+		}
+		columns = append(columns, trend)
+	}
+	if r.intercept {
+		ones := make([]float64, h)
+		for t := range ones {
+			ones[t] = 1
+		}
+		columns = append(columns, ones)
+	}
+	return columns
+}
+
+// validateRegressors checks that xreg holds finite regressors of length n.
+func validateRegressors(xreg [][]float64, n int) error {
+	if len(xreg) == 0 {
+		return fmt.Errorf("%w: no regressors given", ErrInvalidRegressors)
+	}
+	for j, x := range xreg {
+		if len(x) != n {
+			return fmt.Errorf("%w: regressor %d has %d values, expected %d",
+				ErrInvalidRegressors, j, len(x), n)
+		}
+		if !isFinite(x) {
+			return fmt.Errorf("%w: regressor %d", ErrNonFiniteInput, j)
+		}
+	}
+	return nil
+}
+
+// differenceWith applies the differencing operators of spec to series.
+func differenceWith(spec Config, series []float64) ([]float64, error) {
+	scratch, err := spec.clone()
+	if err != nil {
+		return nil, err
+	}
+	return differentiate(scratch, series, scratch.D > 0 && scratch.m > 0, scratch.d > 0)
+}
+
+// estimate sets beta. All methods start from the least squares estimate
+// on the differenced series. Hannan-Rissanen then alternates its ARMA step
+// on the regression errors with generalized least squares on the series
+// prewhitened by the fitted ARMA filter; the other methods optimize beta
+// jointly with the ARMA coefficients.
+func (r *regression) estimate(spec Config, method Method) error {
+	beta, err := leastSquares(r.regressors, r.response)
+	if err != nil {
+		return err
+	}
+	r.beta = beta
+	if method == MethodHannanRissanen {
+		return r.estimateGLS(spec)
+	}
+	scratch, err := spec.clone()
+	if err != nil {
+		return err
+	}
+	numARMA := scratch.getNumParamsP() + scratch.getNumParamsQ()
+	x0 := make([]float64, numARMA+len(beta))
+	copy(x0[numARMA:], beta)
+	x, value := minimizeBFGS(r.jointObjective(scratch, method), x0)
+	if math.IsInf(value, 0) || math.IsNaN(value) {
+		return fmt.Errorf("%w: regression with ARIMA errors could not be estimated", ErrSingularSystem)
+	}
+	copy(r.beta, x[numARMA:])
+	return nil
+}
+
+// jointObjective returns the estimation objective of method as a function
+// of the ARMA coefficients followed by beta. It overwrites the coefficients
+// of params.
+func (r *regression) jointObjective(params Config, method Method) func([]float64) float64 {
+	numARMA := params.getNumParamsP() + params.getNumParamsQ()
+	return func(x []float64) float64 {
+		errors := r.stationaryErrors(x[numARMA:])
+		switch method {
+		case MethodML, MethodCSSML:
+			return exactObjective(errors, params)(x[:numARMA])
+		default:
+			return cssObjective(errors, params)(x[:numARMA])
+		}
+	}
+}
+
+// stationaryErrors returns the differenced regression errors for beta.
+func (r *regression) stationaryErrors(beta []float64) []float64 {
+	errors := append([]float64(nil), r.response...)
+	for j, x := range r.regressors {
+		for t := range errors {
+			errors[t] -= beta[j] * x[t]
+		}
+	}
+	return errors
+}
+
+// errorsOf returns y - X beta on the original scale.
+func (r *regression) errorsOf(data []float64) []float64 {
+	errors := append([]float64(nil), data...)
+	for j, x := range r.design(r.xreg, 0, len(data)) {
+		for t := range errors {
+			errors[t] -= r.beta[j] * x[t]
+		}
+	}
+	return errors
+}
+
+// effect returns X beta for the h observations following the first
+// offset ones, with xreg holding the values of the exogenous regressors.
+func (r *regression) effect(xreg [][]float64, offset, h int) []float64 {
+	return r.combine(r.design(xreg, offset, h), h)
+}
+
+// fittedEffect returns X beta over the first n observations.
+func (r *regression) fittedEffect(n int) []float64 {
+	return r.combine(r.design(r.xreg, 0, n), n)
+}
+
+func (r *regression) combine(design [][]float64, h int) []float64 {
+	effect := make([]float64, h)
+	for j, x := range design {
+		for t := range effect {
+			effect[t] += r.beta[j] * x[t]
+		}
+	}
+	return effect
+}
+
+// estimateGLS refines beta by feasible generalized least squares, fitting
+// the ARMA errors by Hannan-Rissanen in between, until beta settles.
+func (r *regression) estimateGLS(spec Config) error {
+	scratch, err := spec.clone()
+	if err != nil {
+		return err
+	}
+	if scratch.getNumParamsP()+scratch.getNumParamsQ() == 0 {
+		// white noise errors, least squares is already GLS
+		return nil
+	}
+	for i := 0; i < maxIterationsGLS; i++ {
+		if err := estimateARMA(r.stationaryErrors(r.beta), &scratch, 1, maxIterationForHannanRissanen); err != nil {
+			return err
+		}
+		if _, err := enforceAdmissible(scratch, AdmissibilityDamp); err != nil {
+			return err
+		}
+		// the residual filter of the errors turns them into white noise
+		regressors := make([][]float64, len(r.regressors))
+		for j, x := range r.regressors {
+			regressors[j] = computeResiduals(scratch, x)
+		}
+		beta, err := leastSquares(regressors, computeResiduals(scratch, r.response))
+		if err != nil {
+			return err
+		}
+		converged := true
+		for j := range beta {
+			if math.Abs(beta[j]-r.beta[j]) > 1e-8*(1+math.Abs(r.beta[j])) {
+				converged = false
+			}
+		}
+		r.beta = beta
+		if converged {
+			break
+		}
+	}
+	return nil
+}
+
+// leastSquares regresses the differenced response on the differenced
+// regressors, skipping missing values.
+func leastSquares(regressors [][]float64, response []float64) ([]float64, error) {
+	regressors, response = completeCases(regressors, response)
+	n := len(response)
+	if n <= len(regressors) {
+		return nil, fmt.Errorf("%w: %d observed differenced points for %d regressors",
+			ErrInsufficientData, n, len(regressors))
+	}
+	zt := matrix.NewInsightsMatrixWithData(regressors, false)
+	ztz := zt.ComputeAAT()
+	zty := zt.TimesVector(matrix.NewInsightVectorWithData(response, false))
+	solution := ztz.SolveSPDIntoVector(zty, -1)
+	if solution == nil || !isFinite(solution.DeepCopy()) {
+		return nil, fmt.Errorf("%w: regressors are collinear after differencing", ErrSingularSystem)
+	}
+	return solution.DeepCopy(), nil
+}
@@ -0,0 +1,304 @@
+// Package boxcox implements the Box-Cox power transformation, the automatic
+// choice of its parameter and the back-transformation of forecasts.
+package boxcox
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/DoOR-Team/timeseries_forecasting/arima/matrix"
+)
+
+// Bounds of the automatic lambda search, as in R's forecast package.
+const (
+	LowerLambda = -1.0
+	UpperLambda = 2.0
+)
+
+var (
+	// ErrNonPositive is returned when the data contain values <= 0, for
+	// which the transform is not defined.
+	ErrNonPositive = errors.New("Box-Cox transform needs positive data")
+	// ErrInvalidArgument is returned for unsupported parameters, including
+	// series too short to choose lambda.
+	ErrInvalidArgument = errors.New("invalid argument")
+)
+
+// Method selects how Lambda chooses the transformation parameter.
+type Method int
+
+const (
+	// Guerrero chooses lambda to make the coefficient of variation of
+	// sd/mean^(1-lambda) across subseries as small as possible.
+	Guerrero Method = iota
+	// ProfileLikelihood maximizes the Box-Cox profile log-likelihood of a
+	// linear trend, plus seasonal dummies for seasonal data.
+	ProfileLikelihood
+)
+
+// Transform configures an optional Box-Cox transformation of the input of
+// a forecasting model. Forecasts are made on the transformed scale and
+// transformed back, which gives the median of the forecast distribution
+// unless BiasAdjust is set.
+type Transform struct {
+	Lambda float64
+	// Auto chooses Lambda from the data with Method.
+	Auto       bool
+	Method     Method
+	BiasAdjust bool
+}
+
+// Resolve returns t with Lambda chosen from data, of seasonal period m
+// (m < 2 for non-seasonal data), if Auto is set.
+func (t Transform) Resolve(data []float64, m int) (Transform, error) {
+	if !t.Auto {
+		return t, nil
+	}
+	lambda, err := Lambda(data, m, t.Method)
+	if err != nil {
+		return Transform{}, err
+	}
+	t.Lambda = lambda
+	t.Auto = false
+	return t, nil
+}
+
+// Apply returns the Box-Cox transform of data: (y^lambda - 1)/lambda, or
+// log(y) for lambda = 0. Missing values (NaN) stay missing.
+func Apply(data []float64, lambda float64) ([]float64, error) {
+	if err := checkPositive(data); err != nil {
+		return nil, err
+	}
+	transformed := make([]float64, len(data))
+	for i, y := range data {
+		if lambda == 0 {
+			transformed[i] = math.Log(y)
+		} else {
+			transformed[i] = (math.Pow(y, lambda) - 1) / lambda
+		}
+	}
+	return transformed, nil
+}
+
+// Invert returns the inverse transform of data. Values outside the range
+// of the transform map to 0 or +Inf.
+func Invert(data []float64, lambda float64) []float64 {
+	original := make([]float64, len(data))
+	for i, w := range data {
+		original[i] = invert(w, lambda)
+	}
+	return original
+}
+
+// InvertBiasAdjusted returns the mean, rather than the median, of the
+// back-transformed forecast distribution given transformed point forecasts
+// and their variances: the inverse transform times
+// 1 + variance*(1-lambda)/(2*(lambda*w+1)^2).
+func InvertBiasAdjusted(forecasts, variances []float64, lambda float64) []float64 {
+	adjusted := Invert(forecasts, lambda)
+	for i, w := range forecasts {
+		scaled := lambda*w + 1
+		if scaled <= 0 {
+			continue
+		}
+		adjusted[i] *= 1 + variances[i]*(1-lambda)/(2*scaled*scaled)
+	}
+	return adjusted
+}
+
+func invert(w, lambda float64) float64 {
+	if lambda == 0 {
+		return math.Exp(w)
+	}
+	scaled := lambda*w + 1
+	if scaled <= 0 {
+		if lambda > 0 {
+			return 0
+		}
+		return math.Inf(1)
+	}
+	return math.Pow(scaled, 1/lambda)
+}
+
+// Lambda chooses the Box-Cox parameter for data of seasonal period m
+// within [LowerLambda, UpperLambda], ignoring missing values (NaN).
+func Lambda(data []float64, m int, method Method) (float64, error) {
+	if err := checkPositive(data); err != nil {
+		return 0, err
+	}
+	switch method {
+	case Guerrero:
+		return guerrero(data, m)
+	case ProfileLikelihood:
+		return profileLikelihood(data, m)
+	}
+	return 0, fmt.Errorf("%w: unknown lambda method %d", ErrInvalidArgument, method)
+}
+
+// guerrero splits the last whole periods of data into subseries of length
+// max(m, 2) and minimizes the coefficient of variation of
+// sd/mean^(1-lambda) over them.
+func guerrero(data []float64, m int) (float64, error) {
+	period := m
+	if period < 2 {
+		period = 2
+	}
+	numSubseries := len(data) / period
+	if numSubseries < 2 {
+		return 0, fmt.Errorf("%w: Guerrero's method needs two subseries of length %d, have %d points",
+			ErrInvalidArgument, period, len(data))
+	}
+	start := len(data) - numSubseries*period
+	means := make([]float64, numSubseries)
+	sds := make([]float64, numSubseries)
+	for i := range means {
+		subseries := data[start+i*period : start+(i+1)*period]
+		means[i], sds[i] = meanAndSD(subseries)
+	}
+	ratios := make([]float64, numSubseries)
+	coefficientOfVariation := func(lambda float64) float64 {
+		for i := range ratios {
+			ratios[i] = sds[i] / math.Pow(means[i], 1-lambda)
+		}
+		mean, sd := meanAndSD(ratios)
+		return sd / mean
+	}
+	return goldenSection(coefficientOfVariation, LowerLambda, UpperLambda), nil
+}
+
+// profileLikelihood evaluates the profile log-likelihood on a grid of
+// step 0.05 and returns its maximizer.
+func profileLikelihood(data []float64, m int) (float64, error) {
+	var times []int
+	var observed []float64
+	for t, y := range data {
+		if !math.IsNaN(y) {
+			times = append(times, t)
+			observed = append(observed, y)
+		}
+	}
+	data = observed
+	n := len(data)
+	trend := make([]float64, n)
+	ones := make([]float64, n)
+	for i, t := range times {
+		trend[i] = float64(t + 1)
+		ones[i] = 1
+	}
+	columns := [][]float64{ones, trend}
+	if m > 1 {
+		for season := 1; season < m; season++ {
+			dummy := make([]float64, n)
+			for i, t := range times {
+				if t%m == season {
+					dummy[i] = 1
+				}
+			}
+			columns = append(columns, dummy)
+		}
+	}
+	if n <= len(columns) {
+		return 0, fmt.Errorf("%w: profile likelihood needs more than %d points, have %d",
+			ErrInvalidArgument, len(columns), n)
+	}
+	design := matrix.NewInsightsMatrixWithData(columns, false)
+	gram := design.ComputeAAT()
+
+	meanLog := 0.0
+	for _, y := range data {
+		meanLog += math.Log(y)
+	}
+	meanLog /= float64(n)
+	geometricMean := math.Exp(meanLog)
+
+	best, bestLogLik := 0.0, math.Inf(-1)
+	for step := 0; LowerLambda+0.05*float64(step) <= UpperLambda+1e-9; step++ {
+		lambda := math.Round((LowerLambda+0.05*float64(step))*100) / 100
+		transformed, err := Apply(data, lambda)
+		if err != nil {
+			return 0, err
+		}
+		// scale by the Jacobian so the likelihoods are comparable
+		scale := math.Pow(geometricMean, lambda-1)
+		for t := range transformed {
+			transformed[t] /= scale
+		}
+		solution := gram.SolveSPDIntoVector(design.TimesVector(matrix.NewInsightVectorWithData(transformed, false)), -1)
+		if solution == nil {
+			return 0, fmt.Errorf("%w: singular trend regression", ErrInvalidArgument)
+		}
+		squareSum := 0.0
+		for t, y := range transformed {
+			for j, x := range columns {
+				y -= solution.Get(j) * x[t]
+			}
+			squareSum += y * y
+		}
+		if logLik := -float64(n) / 2 * math.Log(squareSum); logLik > bestLogLik {
+			best, bestLogLik = lambda, logLik
+		}
+	}
+	return best, nil
+}
+
+// goldenSection returns the minimizer of a unimodal f on [a, b].
+func goldenSection(f func(float64) float64, a, b float64) float64 {
+	const tolerance = 1e-6
+	ratio := (math.Sqrt(5) - 1) / 2
+	c := b - ratio*(b-a)
+	d := a + ratio*(b-a)
+	fc, fd := f(c), f(d)
+	for b-a > tolerance {
+		if fc < fd {
+			b, d, fd = d, c, fc
+			c = b - ratio*(b-a)
+			fc = f(c)
+		} else {
+			a, c, fc = c, d, fd
+			d = a + ratio*(b-a)
+			fd = f(d)
+		}
+	}
+	return (a + b) / 2
+}
+
+// meanAndSD returns the mean and standard deviation of the observed values
+// of data, NaN if there are too few.
+func meanAndSD(data []float64) (mean, sd float64) {
+	observed := 0
+	for _, v := range data {
+		if !math.IsNaN(v) {
+			mean += v
+			observed++
+		}
+	}
+	mean /= float64(observed)
+	for _, v := range data {
+		if !math.IsNaN(v) {
+			sd += (v - mean) * (v - mean)
+		}
+	}
+	return mean, math.Sqrt(sd / float64(observed-1))
+}
+
+// checkPositive checks that every value of data is positive or missing.
+func checkPositive(data []float64) error {
+	observed := 0
+	for i, y := range data {
+		if math.IsNaN(y) {
+			continue
+		}
+		observed++
+		if math.IsInf(y, 0) {
+			return fmt.Errorf("%w: infinite value at %d", ErrInvalidArgument, i)
+		}
+		if y <= 0 {
+			return fmt.Errorf("%w: value %v at %d", ErrNonPositive, y, i)
+		}
+	}
+	if observed == 0 {
+		return fmt.Errorf("%w: every value is missing", ErrInvalidArgument)
+	}
+	return nil
+}
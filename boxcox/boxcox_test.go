@@ -0,0 +1,83 @@
+package boxcox
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestTransform(t *testing.T) {
+	data := []float64{0.5, 1, 2, 10, 100}
+	for _, lambda := range []float64{-1, -0.5, 0, 0.3, 1, 2} {
+		transformed, err := Apply(data, lambda)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, y := range Invert(transformed, lambda) {
+			if math.Abs(y-data[i]) > 1e-9*data[i] {
+				t.Fatalf("lambda %v: round trip of %v gave %v", lambda, data[i], y)
+			}
+		}
+	}
+	if _, err := Apply([]float64{1, 0}, 0.5); !errors.Is(err, ErrNonPositive) {
+		t.Fatalf("expected ErrNonPositive, got %v", err)
+	}
+	if _, err := Apply([]float64{1, math.Inf(1)}, 0.5); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("expected ErrInvalidArgument, got %v", err)
+	}
+	if y := Invert([]float64{-3}, 0.5)[0]; y != 0 {
+		t.Fatalf("expected values below the range to map to 0, got %v", y)
+	}
+
+	// with lambda = 0 the bias-adjusted inverse is the lognormal mean to
+	// second order
+	adjusted := InvertBiasAdjusted([]float64{1}, []float64{0.2}, 0)[0]
+	if want := math.E * 1.1; math.Abs(adjusted-want) > 1e-12 {
+		t.Fatalf("expected %v, got %v", want, adjusted)
+	}
+	if adjusted := InvertBiasAdjusted([]float64{3}, []float64{4}, 1)[0]; adjusted != 4 {
+		t.Fatalf("lambda 1 needs no adjustment, got %v", adjusted)
+	}
+}
+
+func TestLambda(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	// multiplicative noise and seasonality: the log is the right transform
+	multiplicative := make([]float64, 240)
+	// additive noise and seasonality: no transform is needed
+	additive := make([]float64, 240)
+	for i := range multiplicative {
+		level := 3 + 0.01*float64(i)
+		season := math.Sin(2 * math.Pi * float64(i) / 12)
+		multiplicative[i] = math.Exp(level + 0.3*season + 0.1*rng.NormFloat64())
+		additive[i] = 100 + 2*float64(i) + 20*season + 5*rng.NormFloat64()
+	}
+	for _, method := range []Method{Guerrero, ProfileLikelihood} {
+		lambda, err := Lambda(multiplicative, 12, method)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(lambda) > 0.3 {
+			t.Fatalf("method %d: expected lambda near 0 for multiplicative data, got %v", method, lambda)
+		}
+		lambda, err = Lambda(additive, 12, method)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(lambda-1) > 0.5 {
+			t.Fatalf("method %d: expected lambda near 1 for additive data, got %v", method, lambda)
+		}
+	}
+
+	resolved, err := Transform{Auto: true, BiasAdjust: true}.Resolve(multiplicative, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved.Auto || !resolved.BiasAdjust {
+		t.Fatalf("unexpected resolved transform %+v", resolved)
+	}
+	if _, err := Lambda([]float64{1, 2, 3}, 12, Guerrero); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("expected ErrInvalidArgument, got %v", err)
+	}
+}
@@ -0,0 +1,321 @@
+// Package distribution describes probabilistic forecasts: the distribution
+// of every point of a forecast horizon through its mean, variance,
+// quantiles and, for simulated forecasts, sample paths.
+package distribution
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/DoOR-Team/timeseries_forecasting/arima/utils"
+)
+
+// ErrInvalidArgument is returned for malformed inputs, horizons out of
+// range and questions the distribution cannot answer.
+var ErrInvalidArgument = errors.New("invalid argument")
+
+// DefaultPaths is the number of sample paths a bootstrap simulates unless
+// BootstrapOptions says otherwise.
+const DefaultPaths = 1000
+
+// BootstrapOptions controls forecasts whose distribution is simulated with
+// resampled residuals. The zero value selects the defaults.
+type BootstrapOptions struct {
+	// Paths is the number of simulated future paths. Defaults to
+	// DefaultPaths.
+	Paths int
+	// Seed seeds the resampling, so the same seed gives the same
+	// distribution.
+	Seed int64
+}
+
+// NumPaths returns the number of paths to simulate.
+func (o BootstrapOptions) NumPaths() (int, error) {
+	if o.Paths < 0 {
+		return 0, fmt.Errorf("%w: number of bootstrap paths must be positive, got %d", ErrInvalidArgument, o.Paths)
+	}
+	if o.Paths == 0 {
+		return DefaultPaths, nil
+	}
+	return o.Paths, nil
+}
+
+// Forecast is the forecast distribution over a horizon. Slices indexed by
+// horizon step start at the first point ahead.
+type Forecast struct {
+	// Mean and Variance of every point, NaN where unknown.
+	Mean     []float64
+	Variance []float64
+	// Probabilities lists the stored quantile probabilities in increasing
+	// order and Quantiles[i][h] the quantile at Probabilities[i] of point h.
+	Probabilities []float64
+	Quantiles     [][]float64
+	// Paths holds sample paths, one slice per path, for distributions
+	// built from simulation, nil otherwise.
+	Paths [][]float64
+
+	// exact quantile and distribution functions, nil if unknown
+	quantile func(h int, p float64) float64
+	cdf      func(h int, x float64) float64
+}
+
+// Normal returns independent normal marginals with the given means and
+// variances, storing the quantiles at probabilities.
+func Normal(mean, variance, probabilities []float64) (*Forecast, error) {
+	if len(mean) != len(variance) {
+		return nil, fmt.Errorf("%w: %d means but %d variances", ErrInvalidArgument, len(mean), len(variance))
+	}
+	for h, v := range variance {
+		if math.IsNaN(mean[h]) || math.IsInf(mean[h], 0) || !(v >= 0) || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("%w: mean %v and variance %v at step %d", ErrInvalidArgument, mean[h], v, h)
+		}
+	}
+	probabilities, err := sortedProbabilities(probabilities)
+	if err != nil {
+		return nil, err
+	}
+	mean = append([]float64(nil), mean...)
+	variance = append([]float64(nil), variance...)
+	f := &Forecast{
+		Mean:          mean,
+		Variance:      variance,
+		Probabilities: probabilities,
+		quantile: func(h int, p float64) float64 {
+			return mean[h] + math.Sqrt(variance[h])*utils.NormalQuantile(p)
+		},
+		cdf: func(h int, x float64) float64 {
+			sd := math.Sqrt(variance[h])
+			if sd == 0 {
+				if x < mean[h] {
+					return 0
+				}
+				return 1
+			}
+			return utils.NormalCDF((x - mean[h]) / sd)
+		},
+	}
+	f.Quantiles = f.tabulate(f.quantile)
+	return f, nil
+}
+
+// FromSamples returns the empirical distribution of paths, one slice per
+// path of equal lengths, storing the quantiles at probabilities. The
+// paths are kept, not copied.
+func FromSamples(paths [][]float64, probabilities []float64) (*Forecast, error) {
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("%w: no sample paths", ErrInvalidArgument)
+	}
+	horizon := len(paths[0])
+	for i, path := range paths {
+		if len(path) != horizon {
+			return nil, fmt.Errorf("%w: path %d has %d points, path 0 has %d", ErrInvalidArgument, i, len(path), horizon)
+		}
+	}
+	probabilities, err := sortedProbabilities(probabilities)
+	if err != nil {
+		return nil, err
+	}
+	f := &Forecast{
+		Mean:          make([]float64, horizon),
+		Variance:      make([]float64, horizon),
+		Probabilities: probabilities,
+		Paths:         paths,
+	}
+	for h := range f.Mean {
+		f.Mean[h], f.Variance[h] = moments(f.column(h))
+	}
+	f.Quantiles = f.tabulate(func(h int, p float64) float64 {
+		return Quantile(f.column(h), p)
+	})
+	return f, nil
+}
+
+// Horizon returns the number of points the distribution covers.
+func (f *Forecast) Horizon() int {
+	return len(f.Mean)
+}
+
+// Quantile returns the p-quantile of point h, 0 being the first point
+// ahead. Stored quantiles are returned as they are; other probabilities use
+// the exact quantile function, the sample paths or linear interpolation
+// between the stored quantiles, in that order of preference.
+func (f *Forecast) Quantile(h int, p float64) (float64, error) {
+	if err := f.checkStep(h); err != nil {
+		return 0, err
+	}
+	if !(p > 0 && p < 1) {
+		return 0, fmt.Errorf("%w: probability %v outside (0, 1)", ErrInvalidArgument, p)
+	}
+	i := sort.SearchFloat64s(f.Probabilities, p)
+	if i < len(f.Probabilities) && math.Abs(f.Probabilities[i]-p) < 1e-12 {
+		return f.Quantiles[i][h], nil
+	}
+	switch {
+	case f.quantile != nil:
+		return f.quantile(h, p), nil
+	case f.Paths != nil:
+		return Quantile(f.column(h), p), nil
+	case i > 0 && i < len(f.Probabilities):
+		p0, p1 := f.Probabilities[i-1], f.Probabilities[i]
+		q0, q1 := f.Quantiles[i-1][h], f.Quantiles[i][h]
+		return q0 + (p-p0)/(p1-p0)*(q1-q0), nil
+	}
+	return 0, fmt.Errorf("%w: probability %v outside the stored quantiles", ErrInvalidArgument, p)
+}
+
+// Exceedance returns the probability that point h exceeds threshold, from
+// the exact distribution function, the sample paths or linear
+// interpolation between the stored quantiles, in that order of preference.
+func (f *Forecast) Exceedance(h int, threshold float64) (float64, error) {
+	if err := f.checkStep(h); err != nil {
+		return 0, err
+	}
+	switch {
+	case f.cdf != nil:
+		return 1 - f.cdf(h, threshold), nil
+	case f.Paths != nil:
+		above := 0
+		for _, path := range f.Paths {
+			if path[h] > threshold {
+				above++
+			}
+		}
+		return float64(above) / float64(len(f.Paths)), nil
+	}
+	for i := 1; i < len(f.Probabilities); i++ {
+		q0, q1 := f.Quantiles[i-1][h], f.Quantiles[i][h]
+		if threshold < q0 || threshold > q1 {
+			continue
+		}
+		p0, p1 := f.Probabilities[i-1], f.Probabilities[i]
+		if q1 == q0 {
+			return 1 - p1, nil
+		}
+		return 1 - (p0 + (threshold-q0)/(q1-q0)*(p1-p0)), nil
+	}
+	return 0, fmt.Errorf("%w: threshold %v outside the stored quantiles", ErrInvalidArgument, threshold)
+}
+
+// Map returns the distribution of g(Y) for an increasing function g with
+// inverse inverse. Quantiles, paths and the exact distribution functions
+// carry over; Mean and Variance are recomputed from the paths if there are
+// any and NaN otherwise.
+func (f *Forecast) Map(g, inverse func(float64) float64) *Forecast {
+	mapped := &Forecast{
+		Mean:          make([]float64, len(f.Mean)),
+		Variance:      make([]float64, len(f.Mean)),
+		Probabilities: append([]float64(nil), f.Probabilities...),
+		Quantiles:     make([][]float64, len(f.Quantiles)),
+	}
+	for i, quantiles := range f.Quantiles {
+		mapped.Quantiles[i] = mapSlice(quantiles, g)
+	}
+	if f.quantile != nil {
+		quantile := f.quantile
+		mapped.quantile = func(h int, p float64) float64 {
+			return g(quantile(h, p))
+		}
+	}
+	if f.cdf != nil {
+		cdf := f.cdf
+		mapped.cdf = func(h int, x float64) float64 {
+			return cdf(h, inverse(x))
+		}
+	}
+	if f.Paths != nil {
+		mapped.Paths = make([][]float64, len(f.Paths))
+		for i, path := range f.Paths {
+			mapped.Paths[i] = mapSlice(path, g)
+		}
+	}
+	for h := range mapped.Mean {
+		mapped.Mean[h], mapped.Variance[h] = math.NaN(), math.NaN()
+		if mapped.Paths != nil {
+			mapped.Mean[h], mapped.Variance[h] = moments(mapped.column(h))
+		}
+	}
+	return mapped
+}
+
+// Quantile returns the p-quantile of the sorted sample by linear
+// interpolation between order statistics, R's default type 7.
+func Quantile(sorted []float64, p float64) float64 {
+	n := len(sorted)
+	if n == 0 {
+		return math.NaN()
+	}
+	h := p * float64(n-1)
+	lo := int(math.Floor(h))
+	if lo >= n-1 {
+		return sorted[n-1]
+	}
+	if lo < 0 {
+		return sorted[0]
+	}
+	return sorted[lo] + (h-float64(lo))*(sorted[lo+1]-sorted[lo])
+}
+
+// tabulate evaluates quantile at every stored probability and step.
+func (f *Forecast) tabulate(quantile func(h int, p float64) float64) [][]float64 {
+	quantiles := make([][]float64, len(f.Probabilities))
+	for i, p := range f.Probabilities {
+		quantiles[i] = make([]float64, len(f.Mean))
+		for h := range quantiles[i] {
+			quantiles[i][h] = quantile(h, p)
+		}
+	}
+	return quantiles
+}
+
+// column returns the sorted values of the paths at step h.
+func (f *Forecast) column(h int) []float64 {
+	column := make([]float64, len(f.Paths))
+	for i, path := range f.Paths {
+		column[i] = path[h]
+	}
+	sort.Float64s(column)
+	return column
+}
+
+func (f *Forecast) checkStep(h int) error {
+	if h < 0 || h >= f.Horizon() {
+		return fmt.Errorf("%w: step %d outside the horizon of %d points", ErrInvalidArgument, h, f.Horizon())
+	}
+	return nil
+}
+
+// sortedProbabilities returns a sorted copy of probabilities without
+// duplicates, checking that they lie in (0, 1).
+func sortedProbabilities(probabilities []float64) ([]float64, error) {
+	sorted := append([]float64(nil), probabilities...)
+	sort.Float64s(sorted)
+	unique := sorted[:0]
+	for _, p := range sorted {
+		if !(p > 0 && p < 1) {
+			return nil, fmt.Errorf("%w: probability %v outside (0, 1)", ErrInvalidArgument, p)
+		}
+		if len(unique) == 0 || unique[len(unique)-1] != p {
+			unique = append(unique, p)
+		}
+	}
+	return unique, nil
+}
+
+// moments returns the mean and sample variance of values, the variance
+// NaN for a single value.
+func moments(values []float64) (mean, variance float64) {
+	if len(values) < 2 {
+		return utils.ComputeMean(values), math.NaN()
+	}
+	return utils.ComputeMean(values), utils.ComputeVariance(values)
+}
+
+func mapSlice(values []float64, g func(float64) float64) []float64 {
+	mapped := make([]float64, len(values))
+	for i, v := range values {
+		mapped[i] = g(v)
+	}
+	return mapped
+}
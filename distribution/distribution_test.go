@@ -0,0 +1,111 @@
+package distribution
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestNormal(t *testing.T) {
+	f, err := Normal([]float64{10, 20}, []float64{4, 9}, []float64{0.975, 0.5, 0.025, 0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Probabilities) != 3 || f.Probabilities[0] != 0.025 || f.Probabilities[2] != 0.975 {
+		t.Fatalf("expected sorted unique probabilities, got %v", f.Probabilities)
+	}
+	if math.Abs(f.Quantiles[2][0]-(10+2*1.959963984540054)) > 1e-6 {
+		t.Fatalf("97.5%% quantile: got %v", f.Quantiles[2][0])
+	}
+	q, err := f.Quantile(1, 0.9)
+	if err != nil || math.Abs(q-(20+3*1.2815515655446004)) > 1e-6 {
+		t.Fatalf("90%% quantile: got %v, %v", q, err)
+	}
+	p, err := f.Exceedance(1, 20+3*1.959963984540054)
+	if err != nil || math.Abs(p-0.025) > 1e-6 {
+		t.Fatalf("exceedance: got %v, %v", p, err)
+	}
+
+	// a monotone transform carries quantiles and probabilities over
+	exp := f.Map(math.Exp, math.Log)
+	if math.Abs(exp.Quantiles[1][0]-math.Exp(10)) > 1e-6 || !math.IsNaN(exp.Mean[0]) {
+		t.Fatalf("mapped median %v, mean %v", exp.Quantiles[1][0], exp.Mean[0])
+	}
+	if p, _ := exp.Exceedance(0, math.Exp(10)); math.Abs(p-0.5) > 1e-9 {
+		t.Fatalf("mapped exceedance of the median: got %v", p)
+	}
+
+	if _, err := f.Quantile(2, 0.5); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("expected ErrInvalidArgument for a step past the horizon, got %v", err)
+	}
+	if _, err := Normal([]float64{0}, []float64{-1}, nil); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("expected ErrInvalidArgument for a negative variance, got %v", err)
+	}
+	if _, err := Normal([]float64{0}, []float64{1}, []float64{1}); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("expected ErrInvalidArgument for probability 1, got %v", err)
+	}
+}
+
+func TestFromSamples(t *testing.T) {
+	rng := rand.New(rand.NewSource(5))
+	paths := make([][]float64, 20000)
+	for i := range paths {
+		first := rng.NormFloat64()
+		paths[i] = []float64{first, first + rng.NormFloat64()}
+	}
+	f, err := FromSamples(paths, []float64{0.1, 0.5, 0.9})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(f.Mean[1]) > 0.05 || math.Abs(f.Variance[1]-2) > 0.1 {
+		t.Fatalf("expected mean 0 and variance 2, got %v and %v", f.Mean[1], f.Variance[1])
+	}
+	if math.Abs(f.Quantiles[2][0]-1.2816) > 0.05 {
+		t.Fatalf("90%% quantile: got %v", f.Quantiles[2][0])
+	}
+	if q, _ := f.Quantile(0, 0.975); math.Abs(q-1.96) > 0.08 {
+		t.Fatalf("97.5%% quantile from the paths: got %v", q)
+	}
+	if p, _ := f.Exceedance(1, 0); math.Abs(p-0.5) > 0.02 {
+		t.Fatalf("exceedance of 0: got %v", p)
+	}
+
+	// without paths only the stored quantiles are known
+	stored := &Forecast{
+		Mean:          []float64{math.NaN()},
+		Variance:      []float64{math.NaN()},
+		Probabilities: []float64{0.1, 0.5, 0.9},
+		Quantiles:     [][]float64{{1}, {2}, {4}},
+	}
+	if q, _ := stored.Quantile(0, 0.7); math.Abs(q-3) > 1e-9 {
+		t.Fatalf("interpolated quantile: got %v", q)
+	}
+	if p, _ := stored.Exceedance(0, 3); math.Abs(p-0.3) > 1e-9 {
+		t.Fatalf("interpolated exceedance: got %v", p)
+	}
+	if _, err := stored.Exceedance(0, 5); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("expected ErrInvalidArgument beyond the stored quantiles, got %v", err)
+	}
+	if _, err := FromSamples([][]float64{{1, 2}, {1}}, nil); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("expected ErrInvalidArgument for ragged paths, got %v", err)
+	}
+}
+
+func TestQuantileAndOptions(t *testing.T) {
+	sorted := []float64{1, 2, 4, 8}
+	for _, c := range []struct{ p, q float64 }{{0, 1}, {0.5, 3}, {0.75, 5}, {1, 8}} {
+		if q := Quantile(sorted, c.p); math.Abs(q-c.q) > 1e-12 {
+			t.Fatalf("%v-quantile: expected %v, got %v", c.p, c.q, q)
+		}
+	}
+	if !math.IsNaN(Quantile(nil, 0.5)) {
+		t.Fatal("expected NaN for an empty sample")
+	}
+	if n, err := (BootstrapOptions{}).NumPaths(); err != nil || n != DefaultPaths {
+		t.Fatalf("expected %d default paths, got %d, %v", DefaultPaths, n, err)
+	}
+	if _, err := (BootstrapOptions{Paths: -1}).NumPaths(); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("expected ErrInvalidArgument for negative paths, got %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+package holtwinters
+
+import (
+	"errors"
+	"math/rand"
+
+	"github.com/DoOR-Team/timeseries_forecasting/distribution"
+)
+
+// Interval is a prediction interval at confidence Level for the m points
+// following y.
+type Interval struct {
+	Level        float64
+	Lower, Upper []float64
+}
+
+// ForecastBootstrap returns Forecast together with prediction intervals at
+// levels (95% if none is given) for the m points following y. Future paths
+// continue the smoothing equations from the end of y with one-step errors
+// resampled from the centred in-sample one-step errors, and the bounds are
+// the empirical quantiles of the paths. The last m forecasts are made from
+// the end of y too, the origin of the intervals, rather than m steps
+// before the point as in Forecast.
+func ForecastBootstrap(y []float64, alpha, beta, gamma float64, period, m int, levels []float64, opts distribution.BootstrapOptions) ([]float64, []Interval, error) {
+	forecast, err := Forecast(y, alpha, beta, gamma, period, m)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(levels) == 0 {
+		levels = []float64{0.95}
+	}
+	var probabilities []float64
+	for _, level := range levels {
+		if !(level > 0 && level < 1) {
+			return nil, nil, errors.New("confidence levels must satisfy 0.0 < level < 1.0")
+		}
+		probabilities = append(probabilities, (1-level)/2, (1+level)/2)
+	}
+	paths, fromEnd, err := bootstrapPaths(y, alpha, beta, gamma, period, m, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	copy(forecast[len(y):], fromEnd)
+	samples, err := distribution.FromSamples(paths, probabilities)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	intervals := make([]Interval, len(levels))
+	for j, level := range levels {
+		intervals[j] = Interval{Level: level, Lower: make([]float64, m), Upper: make([]float64, m)}
+		for h := 0; h < m; h++ {
+			if intervals[j].Lower[h], err = samples.Quantile(h, (1-level)/2); err != nil {
+				return nil, nil, err
+			}
+			if intervals[j].Upper[h], err = samples.Quantile(h, (1+level)/2); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	return forecast, intervals, nil
+}
+
+// ForecastDistribution returns the distribution of the m points following
+// y over future paths simulated as in ForecastBootstrap, with quantiles
+// stored at probabilities. The paths are kept in the result.
+func ForecastDistribution(y []float64, alpha, beta, gamma float64, period, m int, probabilities []float64, opts distribution.BootstrapOptions) (*distribution.Forecast, error) {
+	if err := validateArguments(y, alpha, beta, gamma, period, m); err != nil {
+		return nil, err
+	}
+	paths, _, err := bootstrapPaths(y, alpha, beta, gamma, period, m, opts)
+	if err != nil {
+		return nil, err
+	}
+	return distribution.FromSamples(paths, probabilities)
+}
+
+// bootstrapPaths simulates future paths of the m points following y with
+// resampled one-step errors, one slice per path, and returns them with the
+// forecasts of those points from the end of y.
+func bootstrapPaths(y []float64, alpha, beta, gamma float64, period, m int, opts distribution.BootstrapOptions) ([][]float64, []float64, error) {
+	numPaths, err := opts.NumPaths()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	n := len(y)
+	s := newSmoothing(n, initialLevel(y), initialTrend(y, period), alpha, beta, gamma,
+		seasonalIndicies(y, period, n/period), period)
+	start := period
+	if start < 2 {
+		start = 2
+	}
+	var errs []float64
+	mean := 0.0
+	for i := 2; i < n; i++ {
+		if i >= start {
+			e := y[i] - s.predict(i)
+			errs = append(errs, e)
+			mean += e
+		}
+		s.update(i, y[i])
+	}
+	if len(errs) == 0 {
+		return nil, nil, errors.New("too few points to bootstrap the errors")
+	}
+	mean /= float64(len(errs))
+	for i := range errs {
+		errs[i] -= mean
+	}
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+	paths := make([][]float64, numPaths)
+	for p := range paths {
+		path := s.extend(m)
+		paths[p] = make([]float64, m)
+		for h := 0; h < m; h++ {
+			value := path.predict(n+h) + errs[rng.Intn(len(errs))]
+			path.update(n+h, value)
+			paths[p][h] = value
+		}
+	}
+	forecast := make([]float64, m)
+	for h := range forecast {
+		forecast[h] = s.forecastFrom(n-1, h+1)
+	}
+	return paths, forecast, nil
+}
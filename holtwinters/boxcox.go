@@ -0,0 +1,58 @@
+package holtwinters
+
+import (
+	"github.com/DoOR-Team/timeseries_forecasting/boxcox"
+)
+
+// ForecastBoxCox is Forecast on the Box-Cox transform of y, which must be
+// positive, with the result transformed back. An automatic lambda is
+// chosen from y with the given period. With transform.BiasAdjust the
+// forecasts are means rather than medians, using the variance of the
+// in-sample m-step errors on the transformed scale. Positions Forecast
+// leaves undefined stay 0.
+func ForecastBoxCox(y []float64, alpha, beta, gamma float64, period, m int, transform boxcox.Transform) ([]float64, error) {
+	if err := validateArguments(y, alpha, beta, gamma, period, m); err != nil {
+		return nil, err
+	}
+	resolved, err := transform.Resolve(y, period)
+	if err != nil {
+		return nil, err
+	}
+	transformed, err := boxcox.Apply(y, resolved.Lambda)
+	if err != nil {
+		return nil, err
+	}
+	forecast, err := Forecast(transformed, alpha, beta, gamma, period, m)
+	if err != nil {
+		return nil, err
+	}
+
+	// calculateHoltWinters defines ft[i+m] for i >= 2 and i+m >= period
+	start := 2 + m
+	if start < period {
+		start = period
+	}
+	if start >= len(forecast) {
+		return forecast, nil
+	}
+	defined := forecast[start:]
+	if !resolved.BiasAdjust {
+		copy(defined, boxcox.Invert(defined, resolved.Lambda))
+		return forecast, nil
+	}
+	variance, count := 0.0, 0
+	for t := start; t < len(y); t++ {
+		e := transformed[t] - forecast[t]
+		variance += e * e
+		count++
+	}
+	if count > 0 {
+		variance /= float64(count)
+	}
+	variances := make([]float64, len(defined))
+	for i := range variances {
+		variances[i] = variance
+	}
+	copy(defined, boxcox.InvertBiasAdjusted(defined, variances, resolved.Lambda))
+	return forecast, nil
+}
@@ -78,44 +78,91 @@ func validateArguments(y []float64, alpha, beta, gamma float64, period, m int) (
 // Forecast for m periods.
 func calculateHoltWinters(y []float64, a0, b0, alpha, beta, gamma float64, initialSeasonalIndices []float64, period, m int) []float64 {
 
-	st := make([]float64, len(y))
-	bt := make([]float64, len(y))
-	it := make([]float64, len(y))
+	s := newSmoothing(len(y), a0, b0, alpha, beta, gamma, initialSeasonalIndices, period)
 	ft := make([]float64, len(y)+m)
 
-	st[1] = a0
-	bt[1] = b0
-
-	for i := 0; i < period; i++ {
-		it[i] = initialSeasonalIndices[i]
-	}
-
 	for i := 2; i < len(y); i++ {
 
-		// overall smoothing
-		if (i - period) >= 0 {
-			st[i] = alpha*y[i]/it[i-period] + (1.0-alpha)*(st[i-1]+bt[i-1])
-		} else {
-			st[i] = alpha*y[i] + (1.0-alpha)*(st[i-1]+bt[i-1])
-		}
-
-		// trend smoothing
-		bt[i] = gamma*(st[i]-st[i-1]) + (1-gamma)*bt[i-1]
-
-		// seasonal smoothing
-		if (i - period) >= 0 {
-			it[i] = beta*y[i]/st[i] + (1.0-beta)*it[i-period]
-		}
+		s.update(i, y[i])
 
 		// forecast
 		if (i + m) >= period {
-			ft[i+m] = (st[i] + (float64(m) * bt[i])) * it[i-period+m]
+			ft[i+m] = s.forecastFrom(i, m)
 		}
 	}
 
 	return ft
 }
 
+// smoothing holds the level (st), trend (bt) and seasonal index (it)
+// series of the Holt-Winters equations.
+type smoothing struct {
+	alpha, beta, gamma float64
+	period             int
+	st, bt, it         []float64
+}
+
+func newSmoothing(n int, a0, b0, alpha, beta, gamma float64, initialSeasonalIndices []float64, period int) *smoothing {
+
+	s := &smoothing{
+		alpha:  alpha,
+		beta:   beta,
+		gamma:  gamma,
+		period: period,
+		st:     make([]float64, n),
+		bt:     make([]float64, n),
+		it:     make([]float64, n),
+	}
+
+	s.st[1] = a0
+	s.bt[1] = b0
+
+	for i := 0; i < period; i++ {
+		s.it[i] = initialSeasonalIndices[i]
+	}
+	return s
+}
+
+// update applies the smoothing equations to the observation y at i.
+func (s *smoothing) update(i int, y float64) {
+	st, bt, it := s.st, s.bt, s.it
+	alpha, beta, gamma, period := s.alpha, s.beta, s.gamma, s.period
+
+	// overall smoothing
+	if (i - period) >= 0 {
+		st[i] = alpha*y/it[i-period] + (1.0-alpha)*(st[i-1]+bt[i-1])
+	} else {
+		st[i] = alpha*y + (1.0-alpha)*(st[i-1]+bt[i-1])
+	}
+
+	// trend smoothing
+	bt[i] = gamma*(st[i]-st[i-1]) + (1-gamma)*bt[i-1]
+
+	// seasonal smoothing
+	if (i - period) >= 0 {
+		it[i] = beta*y/st[i] + (1.0-beta)*it[i-period]
+	}
+}
+
+// predict returns the one-step forecast of the observation at i.
+func (s *smoothing) predict(i int) float64 {
+	return s.forecastFrom(i-1, 1)
+}
+
+// forecastFrom returns the h-step forecast made at origin i.
+func (s *smoothing) forecastFrom(i, h int) float64 {
+	return (s.st[i] + (float64(h) * s.bt[i])) * s.it[i-s.period+h]
+}
+
+// extend returns a copy of s with room for k more observations.
+func (s *smoothing) extend(k int) *smoothing {
+	extended := *s
+	extended.st = append(append([]float64(nil), s.st...), make([]float64, k)...)
+	extended.bt = append(append([]float64(nil), s.bt...), make([]float64, k)...)
+	extended.it = append(append([]float64(nil), s.it...), make([]float64, k)...)
+	return &extended
+}
+
 // See: http://robjhyndman.com/researchtips/hw-initialization/
 func initialLevel(y []float64) float64 {
 	return y[0]
@@ -1,7 +1,11 @@
 package holtwinters
 
 import (
+	"math"
 	"testing"
+
+	"github.com/DoOR-Team/timeseries_forecasting/boxcox"
+	"github.com/DoOR-Team/timeseries_forecasting/distribution"
 )
 
 func TestHoltwinters(t *testing.T) {
@@ -31,6 +35,56 @@ func TestHoltwinters(t *testing.T) {
 	}
 }
 
+func TestForecastBoxCox(t *testing.T) {
+	y := []float64{362, 385, 432, 341, 382, 409, 498, 387, 473, 513,
+		582, 474, 544, 582, 681, 557, 628, 707, 773, 592, 627, 725,
+		854, 661}
+	period, m := 4, 4
+	alpha, beta, gamma := 0.5, 0.4, 0.6
+
+	// lambda 1 only shifts the data by one, which multiplicative smoothing
+	// does not undo exactly, so compare with a forecast of y-1
+	shifted := make([]float64, len(y))
+	for i := range y {
+		shifted[i] = y[i] - 1
+	}
+	expected, err := Forecast(shifted, alpha, beta, gamma, period, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prediction, err := ForecastBoxCox(y, alpha, beta, gamma, period, m, boxcox.Transform{Lambda: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range prediction {
+		want := 0.0
+		if i >= 6 {
+			want = expected[i] + 1
+		}
+		if math.Abs(prediction[i]-want) > 1e-9 {
+			t.Fatalf("point %d: expected %v, got %v", i, want, prediction[i])
+		}
+	}
+
+	median, err := ForecastBoxCox(y, alpha, beta, gamma, period, m, boxcox.Transform{Auto: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mean, err := ForecastBoxCox(y, alpha, beta, gamma, period, m, boxcox.Transform{Auto: true, BiasAdjust: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := len(y); i < len(mean); i++ {
+		if math.IsNaN(median[i]) || median[i] <= 0 || math.Abs(mean[i]/median[i]-1) > 0.05 {
+			t.Fatalf("point %d: implausible median %v and mean %v", i, median[i], mean[i])
+		}
+	}
+	if _, err := ForecastBoxCox([]float64{1, 0, 2, 3, 4, 5, 6, 7}, alpha, beta, gamma, period, m,
+		boxcox.Transform{}); err == nil {
+		t.Fatal("expected an error for non-positive data")
+	}
+}
+
 func Compare(a, b []float64) int {
 	for i := 0; i < len(a) && i < len(b); i++ {
 		switch {
@@ -48,3 +102,78 @@ func Compare(a, b []float64) int {
 	}
 	return 0
 }
+
+func TestForecastBootstrap(t *testing.T) {
+	y := []float64{362, 385, 432, 341, 382, 409, 498, 387, 473, 513,
+		582, 474, 544, 582, 681, 557, 628, 707, 773, 592, 627, 725,
+		854, 661}
+	levels := []float64{0.8, 0.95}
+	forecast, intervals, err := ForecastBootstrap(y, 0.5, 0.4, 0.6, 4, 4, levels, distribution.BootstrapOptions{Seed: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := Forecast(y, 0.5, 0.4, 0.6, 4, 4)
+	if Compare(expected[:len(y)], forecast[:len(y)]) != 0 {
+		t.Fatal("bootstrap changed the in-sample forecasts")
+	}
+	// the future forecasts come from the end of y, like the paths, so every
+	// interval brackets its own forecast
+	for h := 0; h < 4; h++ {
+		for _, interval := range intervals {
+			if f := forecast[len(y)+h]; !(interval.Lower[h] < f && f < interval.Upper[h]) {
+				t.Fatalf("step %d: %v%% interval [%v, %v] misses the forecast %v",
+					h, 100*interval.Level, interval.Lower[h], interval.Upper[h], f)
+			}
+		}
+	}
+	if len(intervals) != 2 || intervals[1].Level != 0.95 {
+		t.Fatalf("expected intervals at %v, got %v", levels, intervals)
+	}
+	for h := 0; h < 4; h++ {
+		inner, outer := intervals[0], intervals[1]
+		if !(outer.Lower[h] < inner.Lower[h] && inner.Lower[h] < inner.Upper[h] && inner.Upper[h] < outer.Upper[h]) {
+			t.Fatalf("point %d: intervals not nested: %v %v", h, inner, outer)
+		}
+	}
+	// the uncertainty of the level accumulates over the horizon
+	if intervals[1].Upper[3]-intervals[1].Lower[3] <= intervals[1].Upper[0]-intervals[1].Lower[0] {
+		t.Fatal("expected wider intervals further ahead")
+	}
+
+	_, again, err := ForecastBootstrap(y, 0.5, 0.4, 0.6, 4, 4, levels, distribution.BootstrapOptions{Seed: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again[1].Upper[3] != intervals[1].Upper[3] {
+		t.Fatal("the same seed gave different intervals")
+	}
+	if _, _, err := ForecastBootstrap(y, 0.5, 0.4, 0.6, 4, 4, []float64{1.5}, distribution.BootstrapOptions{}); err == nil {
+		t.Fatal("expected an error for a level outside (0, 1)")
+	}
+}
+
+func TestForecastDistribution(t *testing.T) {
+	y := []float64{362, 385, 432, 341, 382, 409, 498, 387, 473, 513,
+		582, 474, 544, 582, 681, 557, 628, 707, 773, 592, 627, 725,
+		854, 661}
+	opts := distribution.BootstrapOptions{Paths: 500, Seed: 3}
+	forecastDistribution, err := ForecastDistribution(y, 0.5, 0.4, 0.6, 4, 4, []float64{0.025, 0.975}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, intervals, err := ForecastBootstrap(y, 0.5, 0.4, 0.6, 4, 4, []float64{0.95}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if forecastDistribution.Horizon() != 4 || len(forecastDistribution.Paths) != 500 {
+		t.Fatalf("expected 500 paths of 4 points")
+	}
+	for h := 0; h < 4; h++ {
+		if forecastDistribution.Quantiles[0][h] != intervals[0].Lower[h] || forecastDistribution.Quantiles[1][h] != intervals[0].Upper[h] {
+			t.Fatalf("step %d: quantiles do not match ForecastBootstrap", h)
+		}
+	}
+	if p, _ := forecastDistribution.Exceedance(0, forecastDistribution.Quantiles[1][0]); p > 0.03 {
+		t.Fatalf("exceedance of the 97.5%% quantile: got %v", p)
+	}
+}